@@ -0,0 +1,175 @@
+package signalfx
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+)
+
+// Admin gives access to the internals of a running publisher, for debugging
+// and administration. It is also an http.Handler which serves the debug
+// information as JSON on GET, and performs the action named by the action
+// parameter on POST:
+//
+// 	admin := signalfx.NewAdmin()
+// 	http.Handle("/debug/signalfx", admin)
+// 	go signalfx.PublishToSignalFx(metrics.DefaultRegistry, "<auth_token>", signalfx.Options{
+// 		Admin: admin,
+// 	})
+//
+// The actions are "clear-quarantine", "pause", and "resume", see the methods
+// of the same name.
+type Admin struct {
+	mu sync.Mutex
+	p  *publisher
+
+	// config is the configuration last reloaded, and pending is set when it
+	// was reloaded before the publisher started.
+	config  Config
+	pending *Config
+
+	// ready is set once Ready was called.
+	ready bool
+}
+
+// NewAdmin creates an Admin, to be set in Options.
+func NewAdmin() *Admin {
+	return &Admin{}
+}
+
+func (a *Admin) attach(p *publisher) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.p = p
+	if a.ready {
+		p.warmUp.setReady()
+	}
+	if a.pending != nil {
+		p.reloads <- *a.pending
+		a.pending = nil
+	}
+}
+
+func (a *Admin) publisher() *publisher {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.p
+}
+
+// Quarantined returns the series whose datapoints SignalFX rejected, and
+// which are not sent anymore, with the reason of their rejection.
+func (a *Admin) Quarantined() map[string]string {
+	if p := a.publisher(); p != nil {
+		return p.quarantine.list()
+	}
+	return nil
+}
+
+// ClearQuarantine resumes sending the quarantined series.
+func (a *Admin) ClearQuarantine() {
+	if p := a.publisher(); p != nil {
+		p.quarantine.clear()
+	}
+}
+
+// Pause stops publishing, until Resume is called. The state of the
+// publisher is kept, and publishing resumes with a full flush, e.g. to
+// silence the publisher during a SignalFX maintenance.
+func (a *Admin) Pause() {
+	if p := a.publisher(); p != nil {
+		p.pauser.pause()
+	}
+}
+
+// Resume resumes publishing after Pause, with a full flush.
+func (a *Admin) Resume() {
+	if p := a.publisher(); p != nil {
+		p.pauser.resume()
+	}
+}
+
+// Paused reports whether publishing is paused.
+func (a *Admin) Paused() bool {
+	if p := a.publisher(); p != nil {
+		return p.pauser.isPaused()
+	}
+	return false
+}
+
+// Reload applies the configuration to the publisher, between two flushes.
+// Each configuration replaces the previous one, rather than adding to it.
+// Configurations reloaded before the publisher starts are applied once it
+// does.
+func (a *Admin) Reload(config Config) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.config = config
+	if a.p == nil {
+		a.pending = &config
+		return
+	}
+	select {
+	case <-a.p.reloads:
+		// Superseded by this configuration.
+	default:
+	}
+	a.p.reloads <- config
+}
+
+// Config returns the configuration last reloaded.
+func (a *Admin) Config() Config {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.config
+}
+
+// RolledUp returns the dimensions rolled up, see Rollup.
+func (a *Admin) RolledUp() []string {
+	if p := a.publisher(); p != nil && p.rollup != nil {
+		return p.rollup.keys()
+	}
+	return nil
+}
+
+// Schedule returns the recent ticks of the publisher, oldest first, to
+// diagnose gaps in charts.
+func (a *Admin) Schedule() []Tick {
+	if p := a.publisher(); p != nil {
+		return p.schedule.list()
+	}
+	return nil
+}
+
+// debugInfo is the debug information served by the Admin.
+type debugInfo struct {
+	Paused     bool              `json:"paused"`
+	Quarantine map[string]string `json:"quarantine"`
+	RolledUp   []string          `json:"rolled_up"`
+	Schedule   []Tick            `json:"schedule"`
+}
+
+func (a *Admin) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET":
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(debugInfo{
+			Paused:     a.Paused(),
+			Quarantine: a.Quarantined(),
+			RolledUp:   a.RolledUp(),
+			Schedule:   a.Schedule(),
+		})
+	case "POST":
+		switch r.FormValue("action") {
+		case "clear-quarantine":
+			a.ClearQuarantine()
+		case "pause":
+			a.Pause()
+		case "resume":
+			a.Resume()
+		default:
+			http.Error(w, "unknown action", http.StatusBadRequest)
+		}
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
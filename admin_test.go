@@ -0,0 +1,78 @@
+package signalfx
+
+import (
+	"net/http/httptest"
+	"strings"
+
+	metrics "github.com/rcrowley/go-metrics"
+	. "gopkg.in/check.v1"
+)
+
+func (s *Zuite) TestQuarantine(c *C) {
+	admin := NewAdmin()
+	c.Assert(admin.Quarantined(), IsNil)
+
+	p := newPublisher("", Options{Admin: admin})
+	p.quarantine.add("bad[env=prod]", "invalid status code 400")
+
+	u := p.prepareUpdate()
+	u.metricToDatapoints(NameWithDimensions("bad", map[string]string{"env": "prod"}), metrics.NewCounter())
+	u.metricToDatapoints("good", metrics.NewCounter())
+
+	c.Assert(u.ds, HasLen, 1)
+	c.Assert(u.ds[0].Metric, Equals, "good")
+	c.Assert(u.stats.Quarantined, Equals, 1)
+	c.Assert(admin.Quarantined(), DeepEquals, map[string]string{"bad[env=prod]": "invalid status code 400"})
+
+	admin.ClearQuarantine()
+	c.Assert(admin.Quarantined(), HasLen, 0)
+}
+
+func (s *Zuite) TestAdmin_ServeHTTP(c *C) {
+	admin := NewAdmin()
+	p := newPublisher("", Options{Admin: admin})
+	p.quarantine.add("bad", "rejected")
+
+	w := httptest.NewRecorder()
+	admin.ServeHTTP(w, httptest.NewRequest("GET", "/debug/signalfx", nil))
+	c.Assert(w.Body.String(), Equals, `{"paused":false,"quarantine":{"bad":"rejected"},"rolled_up":null,"schedule":null}`+"\n")
+
+	w = httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "/debug/signalfx", strings.NewReader("action=clear-quarantine"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	admin.ServeHTTP(w, req)
+	c.Assert(w.Code, Equals, 200)
+	c.Assert(p.quarantine.list(), HasLen, 0)
+
+	w = httptest.NewRecorder()
+	admin.ServeHTTP(w, httptest.NewRequest("POST", "/debug/signalfx?action=explode", nil))
+	c.Assert(w.Code, Equals, 400)
+}
+
+func (s *Zuite) TestAdmin_pause(c *C) {
+	admin := NewAdmin()
+	c.Assert(admin.Paused(), Equals, false)
+	p := newPublisher("", Options{Admin: admin})
+
+	flush, paused, resumed := p.pauser.flushing()
+	c.Assert(flush, Equals, true)
+	c.Assert(paused, Equals, false)
+	c.Assert(resumed, Equals, false)
+
+	admin.Pause()
+	c.Assert(admin.Paused(), Equals, true)
+	flush, paused, _ = p.pauser.flushing()
+	c.Assert(flush, Equals, false)
+	c.Assert(paused, Equals, true)
+	_, paused, _ = p.pauser.flushing()
+	c.Assert(paused, Equals, false)
+
+	w := httptest.NewRecorder()
+	admin.ServeHTTP(w, httptest.NewRequest("POST", "/debug/signalfx?action=resume", nil))
+	c.Assert(admin.Paused(), Equals, false)
+	flush, _, resumed = p.pauser.flushing()
+	c.Assert(flush, Equals, true)
+	c.Assert(resumed, Equals, true)
+	_, _, resumed = p.pauser.flushing()
+	c.Assert(resumed, Equals, false)
+}
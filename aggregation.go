@@ -0,0 +1,156 @@
+package signalfx
+
+import (
+	"math"
+	"path"
+	"sort"
+)
+
+// GaugeAggregation is how the values of gauges merged into a series, see
+// CollisionSum, are combined.
+type GaugeAggregation int
+
+const (
+	// GaugeFirst keeps the value of the first metric, as with
+	// CollisionDrop.
+	GaugeFirst GaugeAggregation = iota
+
+	// GaugeLast keeps the value of the last metric.
+	GaugeLast
+
+	// GaugeSum sums the values, e.g. of queue lengths.
+	GaugeSum
+
+	// GaugeMax keeps the largest value, e.g. of lags.
+	GaugeMax
+
+	// GaugeMin keeps the smallest value.
+	GaugeMin
+
+	// GaugeMean averages the values, e.g. of utilizations.
+	GaugeMean
+)
+
+// GaugeAggregationRule sets the aggregation of the gauges whose name matches
+// the pattern, as per path.Match, e.g. "queue.*".
+type GaugeAggregationRule struct {
+	Pattern     string
+	Aggregation GaugeAggregation
+}
+
+// aggregate combines the values per the aggregation.
+func (a GaugeAggregation) aggregate(values []float64) float64 {
+	value := values[0]
+	for _, v := range values[1:] {
+		switch a {
+		case GaugeLast:
+			value = v
+		case GaugeSum, GaugeMean:
+			value += v
+		case GaugeMax:
+			value = math.Max(value, v)
+		case GaugeMin:
+			value = math.Min(value, v)
+		}
+	}
+	if a == GaugeMean {
+		value /= float64(len(values))
+	}
+	return value
+}
+
+// gaugeAggregations selects the aggregation of gauges, by name.
+type gaugeAggregations struct {
+	rules []GaugeAggregationRule
+
+	// matched caches the aggregation of each name.
+	matched map[string]GaugeAggregation
+}
+
+// newGaugeAggregations creates the selection of aggregations configured in
+// the options, dropping the rules with invalid patterns, or returns nil if
+// gauges are not merged.
+func newGaugeAggregations(opt Options, warnf func(format string, v ...interface{})) *gaugeAggregations {
+	if opt.Collisions != CollisionSum || len(opt.GaugeAggregations) == 0 {
+		return nil
+	}
+	a := &gaugeAggregations{matched: make(map[string]GaugeAggregation)}
+	for _, rule := range opt.GaugeAggregations {
+		if _, err := path.Match(rule.Pattern, ""); err != nil {
+			warnf("WARNING: ignoring gauge aggregation of invalid pattern %q: %s", rule.Pattern, err)
+			continue
+		}
+		a.rules = append(a.rules, rule)
+	}
+	return a
+}
+
+// aggregation returns the aggregation of the gauge named name, GaugeFirst if
+// no rule matches it.
+func (a *gaugeAggregations) aggregation(name string) GaugeAggregation {
+	aggregation, ok := a.matched[name]
+	if !ok {
+		for _, rule := range a.rules {
+			if ok, _ := path.Match(rule.Pattern, name); ok {
+				aggregation = rule.Aggregation
+				break
+			}
+		}
+		a.matched[name] = aggregation
+	}
+	return aggregation
+}
+
+// gaugeMerge is the values of the gauges merged into a series.
+type gaugeMerge struct {
+	s           series
+	aggregation GaugeAggregation
+	values      []float64
+
+	// integer is set when the first gauge is a metrics.Gauge.
+	integer bool
+}
+
+// merge defers the value of the gauge being converted, if its series is
+// merged per an aggregation other than GaugeFirst, until all metrics are
+// collected, see settleMerges. It returns whether the value was deferred or
+// dropped.
+func (u *update) merge(name string, value float64, integer bool) bool {
+	if u.merges == nil || !u.gauge {
+		return false
+	}
+	aggregation := u.p.aggregations.aggregation(name)
+	if aggregation == GaugeFirst {
+		return false
+	}
+	s, ok := u.countSeries(name)
+	if !ok {
+		return true
+	}
+	m, ok := u.merges[s.canonical]
+	if !ok {
+		m = &gaugeMerge{s: s, aggregation: aggregation, integer: integer}
+		u.merges[s.canonical] = m
+	}
+	m.values = append(m.values, value)
+	return true
+}
+
+// settleMerges appends the merged gauges, once all metrics are collected.
+func (u *update) settleMerges() {
+	canonicals := make([]string, 0, len(u.merges))
+	for canonical := range u.merges {
+		canonicals = append(canonicals, canonical)
+	}
+	sort.Strings(canonicals)
+	u.gauge = true
+	for _, canonical := range canonicals {
+		m := u.merges[canonical]
+		value := m.aggregation.aggregate(m.values)
+		if m.integer {
+			u.appendGauge(m.s, int64(math.Round(value)))
+		} else {
+			u.appendGaugeF(m.s, value)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package signalfx
+
+import (
+	metrics "github.com/rcrowley/go-metrics"
+	. "gopkg.in/check.v1"
+)
+
+func (s *Zuite) TestGaugeAggregation_aggregate(c *C) {
+	values := []float64{3, 1, 8}
+	for aggregation, expected := range map[GaugeAggregation]float64{
+		GaugeFirst: 3,
+		GaugeLast:  8,
+		GaugeSum:   12,
+		GaugeMax:   8,
+		GaugeMin:   1,
+		GaugeMean:  4,
+	} {
+		c.Assert(aggregation.aggregate(values), Equals, expected, Commentf("%d", aggregation))
+	}
+}
+
+func (s *Zuite) TestGaugeAggregations(c *C) {
+	p, sender := newTestPublisher(Options{
+		Collisions: CollisionSum,
+		GaugeAggregations: []GaugeAggregationRule{
+			{Pattern: "queue.*", Aggregation: GaugeSum},
+			{Pattern: "lag", Aggregation: GaugeMax},
+			{Pattern: "utilization", Aggregation: GaugeMean},
+		},
+	})
+
+	r1, r2 := metrics.NewRegistry(), metrics.NewRegistry()
+	p.opt.Registries = []metrics.Registry{r2}
+	metrics.GetOrRegisterGauge("queue.length", r1).Update(3)
+	metrics.GetOrRegisterGauge("queue.length", r2).Update(4)
+	metrics.GetOrRegisterGaugeFloat64("lag", r1).Update(1.5)
+	metrics.GetOrRegisterGaugeFloat64("lag", r2).Update(0.5)
+	metrics.GetOrRegisterGaugeFloat64("utilization", r1).Update(0.25)
+	metrics.GetOrRegisterGaugeFloat64("utilization", r2).Update(0.75)
+	metrics.GetOrRegisterGauge("threads", r1).Update(10)
+	metrics.GetOrRegisterGauge("threads", r2).Update(20)
+
+	values := func() map[string]string {
+		sent := make(map[string]string)
+		for _, d := range sender.sent {
+			sent[d.Metric] = d.Value.String()
+		}
+		sender.sent = nil
+		return sent
+	}
+
+	c.Assert(p.single(r1), IsNil)
+	c.Assert(values(), DeepEquals, map[string]string{
+		"queue.length": "7",
+		"lag":          "1.5",
+		"utilization":  "0.5",
+		"threads":      "10",
+	})
+
+	// Merged values are sent when they change.
+	metrics.GetOrRegisterGauge("queue.length", r1).Update(4)
+	metrics.GetOrRegisterGauge("queue.length", r2).Update(3)
+	metrics.GetOrRegisterGaugeFloat64("lag", r2).Update(2)
+	c.Assert(p.single(r1), IsNil)
+	c.Assert(values(), DeepEquals, map[string]string{"lag": "2"})
+}
@@ -0,0 +1,37 @@
+package signalfx
+
+// dimensionAllowlist strips the dimensions whose key is not allowed.
+type dimensionAllowlist struct {
+	allowed  map[string]struct{}
+	stripped map[string]struct{}
+}
+
+func newDimensionAllowlist(keys []string) *dimensionAllowlist {
+	allowed := make(map[string]struct{}, len(keys))
+	for _, key := range keys {
+		allowed[key] = struct{}{}
+	}
+	return &dimensionAllowlist{allowed: allowed, stripped: make(map[string]struct{})}
+}
+
+// strip returns the allowed dimensions, and the keys stripped for the first
+// time. The dimensions are copied if modified.
+func (a *dimensionAllowlist) strip(dims map[string]string) (allowed map[string]string, first []string) {
+	allowed = dims
+	copied := false
+	for key := range dims {
+		if _, ok := a.allowed[key]; ok {
+			continue
+		}
+		if !copied {
+			allowed = mergeDimensions(dims)
+			copied = true
+		}
+		delete(allowed, key)
+		if _, ok := a.stripped[key]; !ok {
+			a.stripped[key] = struct{}{}
+			first = append(first, key)
+		}
+	}
+	return allowed, first
+}
@@ -0,0 +1,43 @@
+package signalfx
+
+import (
+	metrics "github.com/rcrowley/go-metrics"
+	. "gopkg.in/check.v1"
+)
+
+func (s *Zuite) TestDimensionAllowlist(c *C) {
+	a := newDimensionAllowlist([]string{"env", "region"})
+
+	dims := map[string]string{"env": "prod", "region": "us"}
+	allowed, first := a.strip(dims)
+	c.Assert(allowed, DeepEquals, dims)
+	c.Assert(first, HasLen, 0)
+
+	dims = map[string]string{"env": "prod", "user": "alice"}
+	allowed, first = a.strip(dims)
+	c.Assert(allowed, DeepEquals, map[string]string{"env": "prod"})
+	c.Assert(dims["user"], Equals, "alice")
+	c.Assert(first, DeepEquals, []string{"user"})
+
+	_, first = a.strip(map[string]string{"user": "bob"})
+	c.Assert(first, HasLen, 0)
+}
+
+func (s *Zuite) TestMetricToDatapoints_allowedDimensions(c *C) {
+	logger := &recordingLogger{}
+	p := newPublisher("", Options{
+		Logger:            logger,
+		AllowedDimensions: []string{"env"},
+		DimensionProviders: []DimensionProvider{DimensionProviderFunc(func() map[string]string {
+			return map[string]string{"pod": "web-1"}
+		})},
+	})
+	p.defaultDims = map[string]string{"host": "a"}
+	u := p.prepareUpdate()
+
+	u.metricToDatapoints(NameWithDimensions("requests", map[string]string{"env": "prod", "user": "alice"}), metrics.NewCounter())
+
+	c.Assert(u.ds, HasLen, 1)
+	c.Assert(u.ds[0].Dimensions, DeepEquals, map[string]string{"env": "prod", "host": "a"})
+	c.Assert(logger.lines, HasLen, 2)
+}
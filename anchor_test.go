@@ -0,0 +1,60 @@
+package signalfx
+
+import (
+	"errors"
+
+	metrics "github.com/rcrowley/go-metrics"
+	. "gopkg.in/check.v1"
+)
+
+func (s *Zuite) TestAnchorCounters(c *C) {
+	p, sender := newTestPublisher(Options{AnchorCounters: true})
+
+	r := metrics.NewRegistry()
+	metrics.GetOrRegisterCounter("requests", r).Inc(3)
+	metrics.GetOrRegisterGauge("queue", r).Update(7)
+	metrics.GetOrRegisterTimer("latency", r)
+
+	values := func() map[string]string {
+		sent := make(map[string]string)
+		for _, d := range sender.sent {
+			sent[d.Metric] = d.Value.String()
+		}
+		sender.sent = nil
+		return sent
+	}
+
+	c.Assert(p.single(r), IsNil)
+	sent := values()
+	c.Assert(sent["requests"], Equals, "0")
+	c.Assert(sent["queue"], Equals, "7")
+	c.Assert(sent["latency.count"], Equals, "0")
+
+	c.Assert(p.single(r), IsNil)
+	sent = values()
+	c.Assert(sent["requests"], Equals, "3")
+	_, ok := sent["latency.count"]
+	c.Assert(ok, Equals, false)
+
+	// Counts are only anchored once, even after the caches are cleared.
+	p.resetCaches()
+	metrics.GetOrRegisterTimer("latency", r).Update(1)
+	c.Assert(p.single(r), IsNil)
+	sent = values()
+	c.Assert(sent["requests"], Equals, "3")
+	c.Assert(sent["latency.count"], Equals, "1")
+}
+
+func (s *Zuite) TestAnchorCounters_failedFlush(c *C) {
+	p, sender := newTestPublisher(Options{AnchorCounters: true})
+	sender.err = errors.New("unreachable")
+
+	r := metrics.NewRegistry()
+	metrics.GetOrRegisterCounter("requests", r).Inc(3)
+
+	c.Assert(p.single(r), NotNil)
+	sender.err = nil
+	c.Assert(p.single(r), IsNil)
+	c.Assert(sender.sent, HasLen, 1)
+	c.Assert(sender.sent[0].Value.String(), Equals, "0")
+}
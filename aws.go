@@ -0,0 +1,29 @@
+package signalfx
+
+import (
+	"time"
+
+	metrics "github.com/rcrowley/go-metrics"
+)
+
+// AWS SDK metrics, with the service and operation of requests as dimensions.
+// Errors also have the error code as a dimension.
+const (
+	AWSRequestsMetric = "aws.requests"
+	AWSErrorsMetric   = "aws.errors"
+)
+
+// RecordAWSRequest records in r a request made to an AWS service which took
+// d, and failed with code unless it is empty. It is used by the signalfxaws
+// and signalfxawsv1 packages, which hook into the AWS SDKs.
+func RecordAWSRequest(r metrics.Registry, service, operation, code string, d time.Duration) {
+	dims := map[string]string{
+		"service":   service,
+		"operation": operation,
+	}
+	metrics.GetOrRegisterTimer(NameWithDimensions(AWSRequestsMetric, dims), r).Update(d)
+	if code != "" {
+		dims["code"] = code
+		metrics.GetOrRegisterCounter(NameWithDimensions(AWSErrorsMetric, dims), r).Inc(1)
+	}
+}
@@ -0,0 +1,20 @@
+package signalfx
+
+import (
+	"time"
+
+	metrics "github.com/rcrowley/go-metrics"
+	. "gopkg.in/check.v1"
+)
+
+func (s *Zuite) TestRecordAWSRequest(c *C) {
+	r := metrics.NewRegistry()
+	RecordAWSRequest(r, "DynamoDB", "GetItem", "", time.Millisecond)
+	RecordAWSRequest(r, "DynamoDB", "GetItem", "ThrottlingException", time.Millisecond)
+
+	dims := map[string]string{"service": "DynamoDB", "operation": "GetItem"}
+	c.Assert(r.Get(NameWithDimensions(AWSRequestsMetric, dims)).(metrics.Timer).Count(), Equals, int64(2))
+	dims["code"] = "ThrottlingException"
+	c.Assert(r.Get(NameWithDimensions(AWSErrorsMetric, dims)).(metrics.Counter).Count(), Equals, int64(1))
+	c.Assert(r.Get(NameWithDimensions(AWSErrorsMetric, map[string]string{"code": ""})), IsNil)
+}
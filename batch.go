@@ -0,0 +1,165 @@
+package signalfx
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/signalfx/golib/datapoint"
+	sfxerrors "github.com/signalfx/golib/errors"
+	"github.com/signalfx/golib/sfxclient"
+)
+
+// datapointOverheadBytes is a conservative estimate of the encoding overhead
+// of a datapoint, besides its name and dimensions: field tags, lengths, type,
+// value and timestamp.
+const datapointOverheadBytes = 32
+
+// dimensionOverheadBytes is a conservative estimate of the encoding overhead
+// of a dimension, besides its key and value.
+const dimensionOverheadBytes = 6
+
+// estimatedSize estimates the uncompressed encoded size of a datapoint.
+func estimatedSize(d *datapoint.Datapoint) int {
+	size := datapointOverheadBytes + len(d.Metric)
+	for key, value := range d.Dimensions {
+		size += dimensionOverheadBytes + len(key) + len(value)
+	}
+	return size
+}
+
+// splitBySize splits datapoints into batches whose estimated size does not
+// exceed max. A datapoint larger than max on its own is sent in its own
+// batch. If max is not positive, all datapoints form a single batch.
+func splitBySize(ds []*datapoint.Datapoint, max int) [][]*datapoint.Datapoint {
+	if len(ds) == 0 {
+		return nil
+	}
+	if max <= 0 {
+		return [][]*datapoint.Datapoint{ds}
+	}
+	var (
+		batches [][]*datapoint.Datapoint
+		start   int
+		size    int
+	)
+	for i, d := range ds {
+		dsize := estimatedSize(d)
+		if i > start && size+dsize > max {
+			batches = append(batches, ds[start:i])
+			start, size = i, 0
+		}
+		size += dsize
+	}
+	return append(batches, ds[start:])
+}
+
+// splitByCount splits the batches such that none has more than max
+// datapoints. If max is not positive, the batches are returned unchanged.
+func splitByCount(batches [][]*datapoint.Datapoint, max int) [][]*datapoint.Datapoint {
+	if max <= 0 {
+		return batches
+	}
+	var split [][]*datapoint.Datapoint
+	for _, batch := range batches {
+		for len(batch) > max {
+			split = append(split, batch[:max])
+			batch = batch[max:]
+		}
+		split = append(split, batch)
+	}
+	return split
+}
+
+// send sends the datapoints of the update, in as many batches as needed,
+// stopping at the first error. Datapoints acknowledged by SignalFX are
+// recorded in the update, and the batches SignalFX rejects as bad requests
+// are bisected to isolate and reject the offending datapoints, so that the
+// rest of the batch is still sent.
+//
+// Acknowledged datapoints are never sent again, even if a later batch
+// fails, so that counters are not counted twice. Datapoints which were not
+// acknowledged are not replayed either, unless buffered, see
+// Options.BufferSize: the next flush sends their series' fresh values,
+// collected at a new time. A failed request which was
+// nonetheless ingested can therefore be followed by a newer value of the
+// same series, but never by a duplicate of the same collection.
+func (u *update) send(ctx context.Context) error {
+	batches := splitByCount(splitBySize(u.ds, u.p.opt.MaxPayloadBytes), u.p.opt.MaxBatchSize)
+	for _, batch := range batches {
+		if err := u.sendBatch(ctx, batch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (u *update) sendBatch(ctx context.Context, batch []*datapoint.Datapoint) error {
+	u.stats.Batches++
+	err := u.p.sender.AddDatapoints(ctx, batch)
+	if err == nil {
+		for _, d := range batch {
+			u.acknowledged[u.keys[d]] = true
+		}
+		u.stats.Emitted += len(batch)
+		return nil
+	}
+	if status, ok := apiStatus(err); !ok || status != http.StatusBadRequest {
+		return err
+	}
+	if len(batch) == 1 {
+		u.reject(batch[0], err)
+		return nil
+	}
+	half := len(batch) / 2
+	if err := u.sendBatch(ctx, batch[:half]); err != nil {
+		return err
+	}
+	return u.sendBatch(ctx, batch[half:])
+}
+
+// Rejection is a datapoint SignalFX rejected, with the reason given.
+type Rejection struct {
+	Point  Point
+	Reason string
+}
+
+// reject records a datapoint rejected by SignalFX, reporting which metric
+// caused the rejection, and quarantines its series. SignalFX accepts or
+// rejects batches as a whole, answering "OK" or an error status, so rejected
+// datapoints are found by bisecting the batches it fails with a 400.
+func (u *update) reject(d *datapoint.Datapoint, err error) {
+	key := u.keys[d]
+	u.acknowledged[key] = true
+	u.stats.Rejected++
+	u.rejectReason(err.Error(), 1)
+	u.p.quarantine.add(key, err.Error())
+	u.p.logf(levelWarning, "WARNING: SignalFX rejected datapoint %s%s=%s, quarantining %q: %s",
+		d.Metric, encodeDimensions(d.Dimensions), d.Value, key, err)
+	if u.p.opt.OnRejected != nil {
+		u.p.opt.OnRejected(Rejection{Point: newPoint(d), Reason: err.Error()})
+	}
+}
+
+// rejectReason counts datapoints rejected for the reason.
+func (u *update) rejectReason(reason string, n int) {
+	if u.stats.RejectReasons == nil {
+		u.stats.RejectReasons = make(map[string]int)
+	}
+	u.stats.RejectReasons[reason] += n
+}
+
+// apiStatus returns the status code of errors returned by the SignalFX API.
+func apiStatus(err error) (int, bool) {
+	if apiErr, ok := cause(err).(sfxclient.SFXAPIError); ok {
+		return apiErr.StatusCode, true
+	}
+	return 0, false
+}
+
+// cause returns the error at the root of the chain the sink annotates its
+// errors with, e.g. the context.Canceled behind "context already closed".
+// The chain does not implement Unwrap, so errors.Is and errors.As do not see
+// through it.
+func cause(err error) error {
+	return sfxerrors.Tail(err)
+}
@@ -0,0 +1,128 @@
+package signalfx
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+
+	metrics "github.com/rcrowley/go-metrics"
+	"github.com/signalfx/golib/datapoint"
+	"github.com/signalfx/golib/sfxclient"
+	. "gopkg.in/check.v1"
+)
+
+func (s *Zuite) TestEstimatedSize(c *C) {
+	c.Assert(estimatedSize(sfxclient.Gauge("abcd", nil, 1)), Equals, 36)
+	c.Assert(estimatedSize(sfxclient.Gauge("abcd", map[string]string{"k": "vv"}, 1)), Equals, 45)
+}
+
+func (s *Zuite) TestSplitBySize(c *C) {
+	var ds []*datapoint.Datapoint
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		ds = append(ds, sfxclient.Gauge(name, nil, 1))
+	}
+
+	c.Assert(splitBySize(nil, 100), HasLen, 0)
+	c.Assert(splitBySize(ds, 0), DeepEquals, [][]*datapoint.Datapoint{ds})
+	c.Assert(splitBySize(ds, 66), DeepEquals, [][]*datapoint.Datapoint{ds[0:2], ds[2:4], ds[4:5]})
+	c.Assert(splitBySize(ds, 10), DeepEquals, [][]*datapoint.Datapoint{ds[0:1], ds[1:2], ds[2:3], ds[3:4], ds[4:5]})
+}
+
+func (s *Zuite) TestSplitByCount(c *C) {
+	var ds []*datapoint.Datapoint
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		ds = append(ds, sfxclient.Gauge(name, nil, 1))
+	}
+
+	c.Assert(splitByCount(nil, 2), HasLen, 0)
+	c.Assert(splitByCount([][]*datapoint.Datapoint{ds}, 0), DeepEquals, [][]*datapoint.Datapoint{ds})
+	c.Assert(splitByCount([][]*datapoint.Datapoint{ds}, 2), DeepEquals, [][]*datapoint.Datapoint{ds[0:2], ds[2:4], ds[4:5]})
+	c.Assert(splitByCount(splitBySize(ds, 66), 1), HasLen, 5)
+}
+
+func (s *Zuite) TestFlush_maxBatchSize(c *C) {
+	var stats FlushStats
+	p, sender := newTestPublisher(Options{MaxBatchSize: 2, OnFlush: func(s FlushStats) { stats = s }})
+
+	r := metrics.NewRegistry()
+	for _, name := range []string{"a", "b", "c"} {
+		metrics.GetOrRegisterCounter(name, r).Inc(1)
+	}
+	c.Assert(p.single(r), IsNil)
+	c.Assert(stats.Batches, Equals, 2)
+	c.Assert(sender.sent, HasLen, 3)
+}
+
+func (s *Zuite) TestFlush_partialFailure(c *C) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests > 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		io.WriteString(w, `"OK"`)
+	}))
+	defer server.Close()
+
+	p := newPublisher("", Options{MaxPayloadBytes: 40})
+	c.Assert(p.connect(), IsNil)
+	p.client.DatapointEndpoint = server.URL
+	p.last.counters["stale"] = 1
+
+	u := p.prepareUpdate()
+	u.appendIfCounterChanged("a", 1)
+	u.appendIfCounterChanged("b", 2)
+	u.appendIfCounterChanged("stale", 3)
+	err := u.flush()
+
+	c.Assert(err, NotNil)
+	c.Assert(requests, Equals, 2)
+	c.Assert(u.stats.Emitted, Equals, 1)
+	c.Assert(p.last.counters, DeepEquals, map[string]int64{"a": 1})
+}
+
+func (s *Zuite) TestFlush_bisectsBadRequests(c *C) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		body, _ := ioutil.ReadAll(r.Body)
+		if bytes.Contains(body, []byte("bad-metric")) {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+		io.WriteString(w, `"OK"`)
+	}))
+	defer server.Close()
+
+	logger := &recordingLogger{}
+	var rejections []Rejection
+	self := metrics.NewRegistry()
+	p := newPublisher("", Options{
+		Logger:      logger,
+		SelfMetrics: self,
+		OnRejected:  func(r Rejection) { rejections = append(rejections, r) },
+	})
+	c.Assert(p.connect(), IsNil)
+	p.client.DatapointEndpoint = server.URL
+	p.client.DisableCompression = true
+
+	u := p.prepareUpdate()
+	for _, name := range []string{"a", "b", "bad-metric", "d"} {
+		u.appendIfCounterChanged(name, 1)
+	}
+	err := u.flush()
+	p.self.record(u.stats)
+
+	c.Assert(err, IsNil)
+	c.Assert(requests, Equals, 5)
+	c.Assert(u.stats.Emitted, Equals, 3)
+	c.Assert(u.stats.Rejected, Equals, 1)
+	c.Assert(logger.lines, HasLen, 1)
+	c.Assert(logger.lines[0], Matches, `WARNING: SignalFX rejected datapoint bad-metric=1, quarantining "bad-metric": .*400.*`)
+	c.Assert(p.quarantine.keys(), DeepEquals, []string{"bad-metric"})
+	c.Assert(rejections, HasLen, 1)
+	c.Assert(rejections[0].Point.Name, Equals, "bad-metric")
+	c.Assert(u.stats.RejectReasons, DeepEquals, map[string]int{rejections[0].Reason: 1})
+	c.Assert(self.Get("signalfx.rejected").(metrics.Counter).Count(), Equals, int64(1))
+}
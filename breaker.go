@@ -0,0 +1,122 @@
+package signalfx
+
+import (
+	"fmt"
+	"time"
+)
+
+// CircuitState is the state of the circuit breaker, see
+// Options.CircuitBreakerThreshold.
+type CircuitState int
+
+const (
+	// CircuitClosed lets flushes through.
+	CircuitClosed CircuitState = iota
+
+	// CircuitOpen skips flushes, after consecutive failures, until the
+	// backoff elapses.
+	CircuitOpen
+
+	// CircuitHalfOpen lets a single flush through, probing whether
+	// SignalFX recovered.
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+	return fmt.Sprintf("CircuitState(%d)", int(s))
+}
+
+// circuitBreaker stops flushing while SignalFX fails, rather than
+// reconnecting and failing again on every tick.
+type circuitBreaker struct {
+	threshold  int
+	backoff    time.Duration
+	maxBackoff time.Duration
+
+	state    CircuitState
+	failures int
+
+	// until is when the open circuit lets a probe through, and next the
+	// backoff of the next opening.
+	until time.Time
+	next  time.Duration
+}
+
+func newCircuitBreaker(opt Options) *circuitBreaker {
+	if opt.CircuitBreakerThreshold <= 0 {
+		return nil
+	}
+	b := &circuitBreaker{
+		threshold:  opt.CircuitBreakerThreshold,
+		backoff:    opt.CircuitBreakerBackoff,
+		maxBackoff: opt.CircuitBreakerMaxBackoff,
+	}
+	if b.backoff <= 0 {
+		b.backoff = 30 * time.Second
+	}
+	if b.maxBackoff <= 0 {
+		b.maxBackoff = 5 * time.Minute
+	}
+	b.next = b.backoff
+	return b
+}
+
+// allowFlush reports whether the circuit lets a flush through, turning it
+// half-open once the backoff of an open circuit elapsed.
+func (p *publisher) allowFlush(now time.Time) bool {
+	b := p.breaker
+	if b == nil || b.state != CircuitOpen {
+		return true
+	}
+	if now.Before(b.until) {
+		return false
+	}
+	p.setCircuit(CircuitHalfOpen, "probing SignalFX")
+	return true
+}
+
+// checkCircuit records the outcome of a flush. The circuit opens after
+// consecutive failures caused by the endpoint, or when the probe of a
+// half-open circuit fails, with an exponential backoff. It closes once a
+// probe succeeds.
+func (p *publisher) checkCircuit(err error, now time.Time) {
+	b := p.breaker
+	if b == nil {
+		return
+	}
+	if err == nil || !endpointFailure(err) {
+		b.failures = 0
+		if b.state == CircuitHalfOpen {
+			b.next = b.backoff
+			p.setCircuit(CircuitClosed, "SignalFX recovered")
+		}
+		return
+	}
+	b.failures++
+	if b.state != CircuitHalfOpen && b.failures < b.threshold {
+		return
+	}
+	b.until = now.Add(b.next)
+	reason := fmt.Sprintf("after %d consecutive failures, retrying in %s: %s", b.failures, b.next, err)
+	if b.next *= 2; b.next > b.maxBackoff {
+		b.next = b.maxBackoff
+	}
+	p.setCircuit(CircuitOpen, reason)
+}
+
+// setCircuit changes the state of the circuit, logging and reporting it.
+func (p *publisher) setCircuit(state CircuitState, reason string) {
+	p.breaker.state = state
+	p.logf(levelWarning, "circuit breaker %s, %s", state, reason)
+	if p.opt.OnCircuitChange != nil {
+		p.opt.OnCircuitChange(state)
+	}
+}
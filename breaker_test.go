@@ -0,0 +1,83 @@
+package signalfx
+
+import (
+	"errors"
+	"time"
+
+	metrics "github.com/rcrowley/go-metrics"
+	"github.com/signalfx/golib/sfxclient"
+	. "gopkg.in/check.v1"
+)
+
+func (s *Zuite) TestCircuitBreaker(c *C) {
+	var states []CircuitState
+	p := newPublisher("", Options{
+		CircuitBreakerThreshold:  2,
+		CircuitBreakerBackoff:    time.Minute,
+		CircuitBreakerMaxBackoff: 3 * time.Minute,
+		OnCircuitChange:          func(state CircuitState) { states = append(states, state) },
+	})
+	down := errors.New("unreachable")
+	now := time.Date(2017, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	// Errors not caused by the endpoint do not count.
+	p.checkCircuit(sfxclient.SFXAPIError{StatusCode: 400}, now)
+	p.checkCircuit(down, now)
+	c.Assert(p.allowFlush(now), Equals, true)
+	p.checkCircuit(down, now)
+	c.Assert(states, DeepEquals, []CircuitState{CircuitOpen})
+	c.Assert(p.allowFlush(now.Add(59*time.Second)), Equals, false)
+
+	// A failed probe opens the circuit for twice as long.
+	c.Assert(p.allowFlush(now.Add(time.Minute)), Equals, true)
+	p.checkCircuit(down, now.Add(time.Minute))
+	c.Assert(p.allowFlush(now.Add(2*time.Minute)), Equals, false)
+	c.Assert(p.allowFlush(now.Add(3*time.Minute)), Equals, true)
+	p.checkCircuit(down, now.Add(3*time.Minute))
+	c.Assert(p.breaker.next, Equals, 3*time.Minute)
+	c.Assert(p.allowFlush(now.Add(6*time.Minute)), Equals, true)
+	p.checkCircuit(nil, now.Add(6*time.Minute))
+	c.Assert(states, DeepEquals, []CircuitState{
+		CircuitOpen, CircuitHalfOpen, CircuitOpen, CircuitHalfOpen, CircuitOpen, CircuitHalfOpen, CircuitClosed,
+	})
+	c.Assert(p.breaker.next, Equals, time.Minute)
+	c.Assert(CircuitHalfOpen.String(), Equals, "half-open")
+}
+
+func (s *Zuite) TestCircuitBreaker_skipsFlushes(c *C) {
+	var flushes int
+	p, sender := newTestPublisher(Options{
+		CircuitBreakerThreshold: 1,
+		OnFlush:                 func(FlushStats) { flushes++ },
+	})
+	sender.err = errors.New("unreachable")
+
+	r := metrics.NewRegistry()
+	metrics.GetOrRegisterCounter("requests", r).Inc(1)
+	c.Assert(p.single(r), ErrorMatches, "unreachable")
+	c.Assert(p.breaker.state, Equals, CircuitOpen)
+	c.Assert(p.single(r), IsNil)
+	c.Assert(flushes, Equals, 1)
+}
+
+func (s *Zuite) TestCircuitBreaker_probeShedsLowPriority(c *C) {
+	p, sender := newTestPublisher(Options{
+		CircuitBreakerThreshold: 1,
+		Priorities:              []PriorityRule{{Pattern: "debug.*", Priority: PriorityLow}},
+	})
+	p.breaker.state = CircuitHalfOpen
+
+	r := metrics.NewRegistry()
+	metrics.GetOrRegisterCounter("debug.gc", r).Inc(1)
+	metrics.GetOrRegisterCounter("requests", r).Inc(1)
+	c.Assert(p.single(r), IsNil)
+	c.Assert(sender.sent, HasLen, 1)
+	c.Assert(sender.sent[0].Metric, Equals, "requests")
+	c.Assert(p.breaker.state, Equals, CircuitClosed)
+
+	// Shed datapoints are sent once the circuit closed.
+	sender.sent = nil
+	c.Assert(p.single(r), IsNil)
+	c.Assert(sender.sent, HasLen, 1)
+	c.Assert(sender.sent[0].Metric, Equals, "debug.gc")
+}
@@ -0,0 +1,121 @@
+package signalfx
+
+import (
+	"context"
+	"time"
+
+	"github.com/signalfx/golib/event"
+)
+
+// budgetThresholds are the utilizations of the DPM budget, in percent, at
+// which warnings are emitted, highest first.
+var budgetThresholds = []int{95, 80}
+
+// BudgetEventType is the type of the SignalFX events sent when the DPM budget
+// is approached, with Options.BudgetEvents.
+const BudgetEventType = "signalfx.budget"
+
+// budgetTracker measures the datapoints per minute against the budget.
+type budgetTracker struct {
+	budget  int
+	flushes []budgetFlush
+
+	// level is the highest threshold currently reached, or 0.
+	level int
+}
+
+// newBudgetTracker measures the datapoints per minute against the
+// DPMBudget, or else the MaxDatapointsPerMinute, or returns nil if neither
+// is set.
+func newBudgetTracker(opt Options) *budgetTracker {
+	switch {
+	case opt.DPMBudget > 0:
+		return &budgetTracker{budget: opt.DPMBudget}
+	case opt.MaxDatapointsPerMinute > 0:
+		return &budgetTracker{budget: opt.MaxDatapointsPerMinute}
+	}
+	return nil
+}
+
+// budgetFlush is the number of datapoints sent by a flush, by prefix.
+type budgetFlush struct {
+	at       time.Time
+	byPrefix map[string]int
+}
+
+// record records the datapoints sent by the update, and returns the
+// threshold newly reached, if any, with the datapoints per minute and the
+// prefixes contributing most to them.
+func (b *budgetTracker) record(u *update, now time.Time) (threshold, dpm int, top string) {
+	flush := budgetFlush{at: now, byPrefix: make(map[string]int)}
+	for _, d := range u.ds {
+		if u.acknowledged[u.keys[d]] {
+			flush.byPrefix[seriesPrefix(d.Metric)]++
+		}
+	}
+	b.flushes = append(b.flushes, flush)
+	for len(b.flushes) != 0 && now.Sub(b.flushes[0].at) >= time.Minute {
+		b.flushes = b.flushes[1:]
+	}
+
+	byPrefix := make(map[string]int)
+	for _, f := range b.flushes {
+		for prefix, n := range f.byPrefix {
+			byPrefix[prefix] += n
+			dpm += n
+		}
+	}
+	level := 0
+	for _, t := range budgetThresholds {
+		if dpm*100 >= b.budget*t {
+			level = t
+			break
+		}
+	}
+	if level <= b.level {
+		b.level = level
+		return 0, dpm, ""
+	}
+	b.level = level
+	return level, dpm, topPrefixes(byPrefix)
+}
+
+// sent returns the datapoints sent over the minute up to now.
+func (b *budgetTracker) sent(now time.Time) int {
+	sent := 0
+	for _, f := range b.flushes {
+		if now.Sub(f.at) >= time.Minute {
+			continue
+		}
+		for _, n := range f.byPrefix {
+			sent += n
+		}
+	}
+	return sent
+}
+
+// checkBudget warns when the datapoints per minute approach the budget.
+func (p *publisher) checkBudget(u *update) {
+	if p.budget == nil {
+		return
+	}
+	now := time.Now()
+	threshold, dpm, top := p.budget.record(u, now)
+	if threshold == 0 {
+		return
+	}
+	p.logf(levelWarning, "WARNING: datapoints per minute at %d%% of the budget (%d/%d), top prefixes=[%s]",
+		threshold, dpm, p.budget.budget, top)
+	if !p.opt.BudgetEvents || p.sender == nil {
+		return
+	}
+	e := event.NewWithProperties(BudgetEventType, event.USERDEFINED, p.defaultDims, map[string]interface{}{
+		"threshold":    threshold,
+		"dpm":          dpm,
+		"budget":       p.budget.budget,
+		"top_prefixes": top,
+	}, now)
+	if err := p.sender.AddEvents(context.Background(), []*event.Event{e}); err != nil {
+		p.logf(levelWarning, "WARNING: unable to send %s event: %s", BudgetEventType, err)
+	}
+}
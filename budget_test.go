@@ -0,0 +1,112 @@
+package signalfx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	metrics "github.com/rcrowley/go-metrics"
+	. "gopkg.in/check.v1"
+)
+
+func (s *Zuite) TestBudget(c *C) {
+	var (
+		mu     sync.Mutex
+		events []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Path, "event") {
+			mu.Lock()
+			events = append(events, r.URL.Path)
+			mu.Unlock()
+		}
+		w.Write([]byte(`"OK"`))
+	}))
+	defer server.Close()
+
+	logger := &recordingLogger{}
+	p := newPublisher("", Options{Logger: logger, DPMBudget: 10, BudgetEvents: true})
+	c.Assert(p.connect(), IsNil)
+	p.client.DatapointEndpoint = server.URL + "/datapoint"
+	p.client.EventEndpoint = server.URL + "/event"
+
+	flush := func(names ...string) {
+		u := p.prepareUpdate()
+		for _, name := range names {
+			u.appendIfCounterChanged(name, 1)
+		}
+		c.Assert(u.flush(), IsNil)
+		p.checkBudget(u)
+	}
+	flush("http.requests", "http.errors", "db.queries", "jobs")
+	c.Assert(logger.lines, HasLen, 0)
+	flush("http.latency", "http.bytes", "db.errors", "jobs.failed")
+	c.Assert(logger.lines, DeepEquals, []string{
+		"WARNING: datapoints per minute at 80% of the budget (8/10), top prefixes=[http=4, db=2, jobs=2]",
+	})
+	flush("http.retries")
+	c.Assert(logger.lines, HasLen, 1)
+	flush("http.timeouts")
+	c.Assert(logger.lines, HasLen, 2)
+	c.Assert(logger.lines[1], Matches, "WARNING: datapoints per minute at 95% of the budget \\(10/10\\).*")
+	c.Assert(events, HasLen, 2)
+}
+
+func (s *Zuite) TestMaxDatapointsPerMinute(c *C) {
+	logger := &recordingLogger{}
+	var stats FlushStats
+	p, sender := newTestPublisher(Options{
+		Logger:                 logger,
+		MaxDatapointsPerMinute: 12,
+		Priorities:             []PriorityRule{{Pattern: "slo.*", Priority: PriorityCritical}},
+		OnFlush:                func(s FlushStats) { stats = s },
+	})
+
+	r := metrics.NewRegistry()
+	metrics.GetOrRegisterCounter("requests", r).Inc(1)
+	metrics.GetOrRegisterHistogram("slo.latency", r, metrics.NewUniformSample(10)).Update(1)
+	metrics.GetOrRegisterHistogram("sizes", r, metrics.NewUniformSample(10)).Update(1)
+	c.Assert(p.single(r), IsNil)
+
+	// The 10 critical datapoints are kept, leaving room for the counters,
+	// the rest of the histogram being shed, percentiles first.
+	c.Assert(stats.Shed, Equals, 9)
+	c.Assert(sender.sent, HasLen, 12)
+	_, ok := newBatch(sender.sent).Get("requests", nil)
+	c.Assert(ok, Equals, true)
+	_, ok = newBatch(sender.sent).Get("sizes.count", nil)
+	c.Assert(ok, Equals, true)
+	c.Assert(logger.lines, DeepEquals, []string{
+		"WARNING: limit of 12 datapoints per minute reached, shed 9 datapoints (percentiles=5, gauges=4)",
+		"WARNING: datapoints per minute at 95% of the budget (12/12), top prefixes=[slo=10, requests=1, sizes=1]",
+	})
+
+	// Once the minute is spent, only critical datapoints are sent.
+	sender.sent = nil
+	metrics.GetOrRegisterCounter("requests", r).Inc(1)
+	metrics.GetOrRegisterHistogram("slo.latency", r, metrics.NewUniformSample(10)).Update(2)
+	c.Assert(p.single(r), IsNil)
+	for _, point := range newBatch(sender.sent) {
+		c.Assert(strings.HasPrefix(point.Name, "slo."), Equals, true)
+	}
+}
+
+func (s *Zuite) TestMaxDatapointsPerMinute_renamedSubmetrics(c *C) {
+	logger := &recordingLogger{}
+	p := newPublisher("", Options{
+		Logger:                 logger,
+		MaxDatapointsPerMinute: 5,
+		SubmetricNames:         ShortSubmetricNames,
+	})
+	p.sender = &fakeSender{}
+
+	r := metrics.NewRegistry()
+	metrics.GetOrRegisterTimer("latency", r).Update(time.Millisecond)
+	c.Assert(p.single(r), IsNil)
+
+	// Submetrics are shed by kind whatever their names.
+	c.Assert(logger.lines[0], Equals,
+		"WARNING: limit of 5 datapoints per minute reached, shed 9 datapoints (percentiles=5, rates=4)")
+}
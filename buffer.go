@@ -0,0 +1,84 @@
+package signalfx
+
+import (
+	"github.com/signalfx/golib/datapoint"
+)
+
+// retryBuffer keeps the datapoints of failed flushes, to resend them with
+// the next flush, so that short outages do not leave gaps in charts.
+type retryBuffer struct {
+	size int
+	ds   []*datapoint.Datapoint
+	keys map[*datapoint.Datapoint]string
+}
+
+func newRetryBuffer(size int) *retryBuffer {
+	if size <= 0 {
+		return nil
+	}
+	return &retryBuffer{size: size, keys: make(map[*datapoint.Datapoint]string)}
+}
+
+// drain adds the buffered datapoints to the update, ahead of its own, and
+// empties the buffer.
+func (b *retryBuffer) drain(u *update) {
+	if b == nil || len(b.ds) == 0 {
+		return
+	}
+	for _, d := range b.ds {
+		u.keys[d] = b.keys[d]
+	}
+	u.stats.Resent = len(b.ds)
+	u.ds = append(b.ds, u.ds...)
+	b.ds = nil
+	b.keys = make(map[*datapoint.Datapoint]string)
+}
+
+// retain buffers the datapoints of the update which SignalFX did not
+// acknowledge, when the flush failed because of the endpoint, timestamped
+// with the time of their collection. Beyond the size of the buffer, the
+// datapoints of the lowest priority are dropped if priorities are
+// configured, and then the oldest, PriorityCritical ones included, for the
+// buffer never to exceed its size.
+func (b *retryBuffer) retain(u *update, err error) {
+	if b == nil || !endpointFailure(err) {
+		return
+	}
+	for _, d := range u.ds {
+		key := u.keys[d]
+		if u.acknowledged[key] || u.p.quarantine.contains(key) {
+			continue
+		}
+		if d.Timestamp.IsZero() {
+			d.Timestamp = u.now
+		}
+		b.ds = append(b.ds, d)
+		b.keys[d] = key
+		u.retained(key)
+		u.stats.Buffered++
+	}
+	if len(b.ds) <= b.size {
+		return
+	}
+	var kept []*datapoint.Datapoint
+	if u.p.priorities != nil {
+		kept, _ = u.p.priorities.shed(b.ds, b.size)
+	} else {
+		kept = b.ds
+	}
+	if len(kept) > b.size {
+		kept = kept[len(kept)-b.size:]
+	}
+	retained := make(map[*datapoint.Datapoint]bool, len(kept))
+	for _, d := range kept {
+		retained[d] = true
+	}
+	for _, d := range b.ds {
+		if !retained[d] {
+			delete(b.keys, d)
+		}
+	}
+	u.stats.BufferDropped += len(b.ds) - len(kept)
+	u.p.logf(levelWarning, "WARNING: retry buffer of %d datapoints full, dropped %d datapoints", b.size, len(b.ds)-len(kept))
+	b.ds = append([]*datapoint.Datapoint(nil), kept...)
+}
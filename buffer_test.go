@@ -0,0 +1,107 @@
+package signalfx
+
+import (
+	"errors"
+	"time"
+
+	metrics "github.com/rcrowley/go-metrics"
+	. "gopkg.in/check.v1"
+)
+
+func (s *Zuite) TestBuffer_resendsFailedFlushes(c *C) {
+	var stats []FlushStats
+	p, sender := newTestPublisher(Options{
+		BufferSize: 10,
+		OnFlush:    func(s FlushStats) { stats = append(stats, s) },
+	})
+	sender.err = errors.New("unreachable")
+
+	r := metrics.NewRegistry()
+	metrics.GetOrRegisterGauge("queue", r).Update(3)
+	c.Assert(p.single(r), ErrorMatches, "unreachable")
+	c.Assert(stats[0].Buffered, Equals, 1)
+	buffered := p.buffer.ds[0]
+	c.Assert(buffered.Timestamp.IsZero(), Equals, false)
+
+	sender.err = nil
+	metrics.GetOrRegisterGauge("queue", r).Update(5)
+	c.Assert(p.single(r), IsNil)
+	c.Assert(stats[1].Resent, Equals, 1)
+	c.Assert(sender.sent, HasLen, 2)
+	c.Assert(sender.sent[0], Equals, buffered)
+	c.Assert(sender.sent[0].Value.String(), Equals, "3")
+	c.Assert(sender.sent[1].Value.String(), Equals, "5")
+	c.Assert(p.buffer.ds, HasLen, 0)
+}
+
+func (s *Zuite) TestBuffer_dropsOldestWhenFull(c *C) {
+	var stats FlushStats
+	p, sender := newTestPublisher(Options{
+		BufferSize: 2,
+		OnFlush:    func(s FlushStats) { stats = s },
+	})
+	sender.err = errors.New("unreachable")
+
+	r := metrics.NewRegistry()
+	metrics.GetOrRegisterGauge("a", r).Update(1)
+	metrics.GetOrRegisterGauge("b", r).Update(2)
+	c.Assert(p.single(r), ErrorMatches, "unreachable")
+	metrics.GetOrRegisterGauge("a", r).Update(3)
+	c.Assert(p.single(r), ErrorMatches, "unreachable")
+	c.Assert(stats.BufferDropped, Equals, 2)
+	c.Assert(p.buffer.ds, HasLen, 2)
+	c.Assert(p.buffer.keys, HasLen, 2)
+}
+
+func (s *Zuite) TestBuffer_capsCriticalDatapoints(c *C) {
+	var stats FlushStats
+	p, sender := newTestPublisher(Options{
+		BufferSize: 3,
+		Priorities: []PriorityRule{{Pattern: "slo.*", Priority: PriorityCritical}},
+		OnFlush:    func(s FlushStats) { stats = s },
+	})
+	sender.err = errors.New("unreachable")
+
+	r := metrics.NewRegistry()
+	for i := 0; i < 10; i++ {
+		metrics.GetOrRegisterGauge("slo.a", r).Update(int64(i))
+		metrics.GetOrRegisterGauge("slo.b", r).Update(int64(i))
+		c.Assert(p.single(r), ErrorMatches, "unreachable")
+		c.Assert(len(p.buffer.ds) <= 3, Equals, true)
+	}
+	c.Assert(stats.BufferDropped, Equals, 2)
+	c.Assert(p.buffer.keys, HasLen, 3)
+
+	// The newest datapoints are kept.
+	c.Assert(p.buffer.ds[0].Value.String(), Equals, "8")
+	c.Assert(p.buffer.ds[2].Value.String(), Equals, "9")
+}
+
+func (s *Zuite) TestBuffer_dropsDuplicatesResent(c *C) {
+	logger := &recordingLogger{}
+	var stats FlushStats
+	at := time.Date(2017, 3, 1, 10, 20, 0, 0, time.UTC)
+	p, sender := newTestPublisher(Options{
+		BufferSize:    10,
+		Timestamps:    TimestampCustom,
+		TimestampFunc: func(time.Time) time.Time { return at },
+		Logger:        logger,
+		OnFlush:       func(s FlushStats) { stats = s },
+	})
+	sender.err = errors.New("unreachable")
+
+	r := metrics.NewRegistry()
+	metrics.GetOrRegisterGauge("queue", r).Update(3)
+	c.Assert(p.single(r), ErrorMatches, "unreachable")
+
+	// The point collected again at the same time is sent once, as buffered.
+	sender.err = nil
+	metrics.GetOrRegisterGauge("queue", r).Update(5)
+	c.Assert(p.single(r), IsNil)
+	c.Assert(stats.Duplicates, Equals, 1)
+	c.Assert(sender.sent, HasLen, 1)
+	c.Assert(sender.sent[0].Value.String(), Equals, "3")
+	c.Assert(logger.lines, DeepEquals, []string{
+		"WARNING: dropped 1 duplicate datapoints, 1 of which with a different value than the one sent",
+	})
+}
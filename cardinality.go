@@ -0,0 +1,115 @@
+package signalfx
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// seriesGuard caps the number of distinct series a publisher emits. Series
+// seen before the cap was reached are always admitted; new series beyond the
+// cap are rejected and counted.
+type seriesGuard struct {
+	max     int
+	seen    map[string]struct{}
+	dropped int64
+}
+
+func newSeriesGuard(max int) *seriesGuard {
+	return &seriesGuard{max: max, seen: make(map[string]struct{})}
+}
+
+// admit reports whether the series identified by key may be emitted.
+func (g *seriesGuard) admit(key string) bool {
+	if g.max <= 0 {
+		return true
+	}
+	if _, ok := g.seen[key]; ok {
+		return true
+	}
+	if len(g.seen) >= g.max {
+		g.dropped++
+		return false
+	}
+	g.seen[key] = struct{}{}
+	return true
+}
+
+// reportTopPrefixes is the number of prefixes listed in cardinality and
+// budget reports.
+const reportTopPrefixes = 5
+
+// cardinalityTracker records the series active during a reporting period.
+type cardinalityTracker struct {
+	active map[string]struct{}
+	last   int
+}
+
+func newCardinalityTracker() *cardinalityTracker {
+	return &cardinalityTracker{active: make(map[string]struct{})}
+}
+
+func (t *cardinalityTracker) observe(key string) {
+	t.active[key] = struct{}{}
+}
+
+// report summarizes the series active since the last report, and starts a
+// new reporting period.
+func (t *cardinalityTracker) report() string {
+	byPrefix := make(map[string]int)
+	for key := range t.active {
+		byPrefix[seriesPrefix(key)]++
+	}
+	top := topPrefixes(byPrefix)
+
+	total := len(t.active)
+	growth := total - t.last
+	t.last = total
+	t.active = make(map[string]struct{}, total)
+
+	return fmt.Sprintf("cardinality report: active series=%d, growth=%+d, top prefixes=[%s]",
+		total, growth, top)
+}
+
+// logCardinalityReport logs the cardinality report, as a warning only when
+// the active series reached MaxSeries.
+func (p *publisher) logCardinalityReport() {
+	active := len(p.cardinality.active)
+	report := p.cardinality.report()
+	if p.opt.MaxSeries > 0 && active >= p.opt.MaxSeries {
+		p.logf(levelWarning, "WARNING: %s, at the limit of %d series", report, p.opt.MaxSeries)
+		return
+	}
+	p.logf(levelInfo, "%s", report)
+}
+
+// topPrefixes formats the prefixes with the highest counts, highest first.
+func topPrefixes(byPrefix map[string]int) string {
+	prefixes := make([]string, 0, len(byPrefix))
+	for prefix := range byPrefix {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Slice(prefixes, func(i, j int) bool {
+		if byPrefix[prefixes[i]] != byPrefix[prefixes[j]] {
+			return byPrefix[prefixes[i]] > byPrefix[prefixes[j]]
+		}
+		return prefixes[i] < prefixes[j]
+	})
+	if len(prefixes) > reportTopPrefixes {
+		prefixes = prefixes[:reportTopPrefixes]
+	}
+	top := make([]string, 0, len(prefixes))
+	for _, prefix := range prefixes {
+		top = append(top, fmt.Sprintf("%s=%d", prefix, byPrefix[prefix]))
+	}
+	return strings.Join(top, ", ")
+}
+
+// seriesPrefix returns the first dot-separated component of a series name,
+// ignoring its dimensions.
+func seriesPrefix(name string) string {
+	if i := strings.IndexAny(name, ".["); i > 0 {
+		return name[:i]
+	}
+	return name
+}
@@ -0,0 +1,75 @@
+package signalfx
+
+import (
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *Zuite) TestSeriesGuard_admit(c *C) {
+	g := newSeriesGuard(2)
+
+	c.Assert(g.admit("a"), Equals, true)
+	c.Assert(g.admit("b"), Equals, true)
+	c.Assert(g.admit("c"), Equals, false)
+	c.Assert(g.admit("a"), Equals, true)
+	c.Assert(g.admit("d"), Equals, false)
+	c.Assert(g.dropped, Equals, int64(2))
+}
+
+func (s *Zuite) TestSeriesGuard_unlimited(c *C) {
+	g := newSeriesGuard(0)
+
+	for _, name := range []string{"a", "b", "c"} {
+		c.Assert(g.admit(name), Equals, true)
+	}
+	c.Assert(g.seen, HasLen, 0)
+	c.Assert(g.dropped, Equals, int64(0))
+}
+
+func (s *Zuite) TestAppendIfCounterChanged_seriesCap(c *C) {
+	p := newPublisher("", Options{MaxSeries: 1})
+
+	u := p.prepareUpdate()
+	u.appendIfCounterChanged("first", 1)
+	u.appendIfCounterChanged("second", 2)
+
+	c.Assert(u.ds, HasLen, 1)
+	c.Assert(u.ds[0].Metric, Equals, "first")
+	c.Assert(u.dropped.series, Equals, 1)
+	c.Assert(u.dropped.example, Equals, "second")
+}
+
+func (s *Zuite) TestCardinalityTracker_report(c *C) {
+	t := newCardinalityTracker()
+	for _, name := range []string{"db.reads", "db.writes", "http.requests", "uptime"} {
+		t.observe(name)
+	}
+
+	c.Assert(t.report(), Equals, "cardinality report: active series=4, growth=+4, top prefixes=[db=2, http=1, uptime=1]")
+
+	t.observe("db.reads")
+	c.Assert(t.report(), Equals, "cardinality report: active series=1, growth=-3, top prefixes=[db=1]")
+}
+
+func (s *Zuite) TestLogCardinalityReport(c *C) {
+	logger, errorLogger := &recordingLogger{}, &recordingLogger{}
+	p := newPublisher("", Options{
+		Logger:                     logger,
+		ErrorLogger:                errorLogger,
+		Summary:                    true,
+		MaxSeries:                  2,
+		CardinalityReportFrequency: time.Minute,
+	})
+	p.cardinality.observe("db.reads")
+	p.logCardinalityReport()
+	c.Assert(logger.lines, DeepEquals, []string{"cardinality report: active series=1, growth=+1, top prefixes=[db=1]"})
+	c.Assert(errorLogger.lines, HasLen, 0)
+
+	p.cardinality.observe("db.reads")
+	p.cardinality.observe("db.writes")
+	p.logCardinalityReport()
+	c.Assert(errorLogger.lines, DeepEquals, []string{
+		"WARNING: cardinality report: active series=2, growth=+1, top prefixes=[db=2], at the limit of 2 series",
+	})
+}
@@ -0,0 +1,158 @@
+package signalfx
+
+import (
+	"math"
+	"path"
+	"time"
+)
+
+// ChangeDetector decides whether the value of a series changed enough since
+// it was last sent to be sent again. Values which did not change are
+// suppressed, until the caches are cleared every FullFrequency.
+type ChangeDetector interface {
+	// Changed reports whether the value is to be sent, given the last value
+	// sent for its series, elapsed ago.
+	Changed(last, value float64, elapsed time.Duration) bool
+}
+
+// ChangeDetectorFunc adapts a function to a ChangeDetector.
+type ChangeDetectorFunc func(last, value float64, elapsed time.Duration) bool
+
+// Changed calls f.
+func (f ChangeDetectorFunc) Changed(last, value float64, elapsed time.Duration) bool {
+	return f(last, value, elapsed)
+}
+
+var (
+	// AlwaysSend sends all values, changed or not.
+	AlwaysSend ChangeDetector = ChangeDetectorFunc(func(float64, float64, time.Duration) bool {
+		return true
+	})
+
+	// ExactChange sends the values which differ from the last one, and is
+	// the default.
+	ExactChange ChangeDetector = ChangeDetectorFunc(func(last, value float64, _ time.Duration) bool {
+		return value != last
+	})
+)
+
+// AbsoluteChange sends the values which differ from the last one by at
+// least delta.
+func AbsoluteChange(delta float64) ChangeDetector {
+	return ChangeDetectorFunc(func(last, value float64, _ time.Duration) bool {
+		return math.Abs(value-last) >= delta
+	})
+}
+
+// RelativeChange sends the values which differ from the last one by at
+// least the fraction of the last one, e.g. 0.01 for 1%. Values moving away
+// from zero are always sent.
+func RelativeChange(fraction float64) ChangeDetector {
+	return ChangeDetectorFunc(func(last, value float64, _ time.Duration) bool {
+		if last == 0 {
+			return value != 0
+		}
+		return math.Abs(value-last) >= fraction*math.Abs(last)
+	})
+}
+
+// RateOfChange sends the values which changed since the last one at a rate
+// of at least perSecond units per second.
+func RateOfChange(perSecond float64) ChangeDetector {
+	return ChangeDetectorFunc(func(last, value float64, elapsed time.Duration) bool {
+		if value == last {
+			return false
+		}
+		if elapsed <= 0 {
+			return true
+		}
+		return math.Abs(value-last)/elapsed.Seconds() >= perSecond
+	})
+}
+
+// ChangeDetectorRule selects the change detector of the series whose name
+// matches the pattern, as per path.Match, e.g. "runtime.memory.*".
+type ChangeDetectorRule struct {
+	Pattern  string
+	Detector ChangeDetector
+}
+
+// changeDetection selects the change detector of series, by name.
+type changeDetection struct {
+	fallback ChangeDetector
+	gauges   ChangeDetector
+	rules    []ChangeDetectorRule
+
+	// matched caches the detector of the rule matching each name, nil if
+	// none does.
+	matched map[string]ChangeDetector
+}
+
+// newChangeDetection creates the selection of change detectors configured
+// in the options, dropping the rules with invalid patterns, or returns nil
+// if the default is used for all series.
+func newChangeDetection(opt Options, warnf func(format string, v ...interface{})) *changeDetection {
+	if opt.ChangeDetector == nil && len(opt.ChangeDetectors) == 0 && opt.GaugeChangeThreshold <= 0 {
+		return nil
+	}
+	c := &changeDetection{
+		fallback: opt.ChangeDetector,
+		matched:  make(map[string]ChangeDetector),
+	}
+	if c.fallback == nil {
+		c.fallback = ExactChange
+	}
+	if opt.GaugeChangeThreshold > 0 {
+		c.gauges = RelativeChange(opt.GaugeChangeThreshold)
+	}
+	for _, rule := range opt.ChangeDetectors {
+		if _, err := path.Match(rule.Pattern, ""); err != nil {
+			warnf("WARNING: ignoring change detector of invalid pattern %q: %s", rule.Pattern, err)
+			continue
+		}
+		c.rules = append(c.rules, rule)
+	}
+	return c
+}
+
+// detector returns the change detector of the series named name, which is
+// the value of a gauge if gauge is set.
+func (c *changeDetection) detector(name string, gauge bool) ChangeDetector {
+	detector, ok := c.matched[name]
+	if !ok {
+		for _, rule := range c.rules {
+			if ok, _ := path.Match(rule.Pattern, name); ok {
+				detector = rule.Detector
+				break
+			}
+		}
+		c.matched[name] = detector
+	}
+	switch {
+	case detector != nil:
+		return detector
+	case gauge && c.gauges != nil:
+		return c.gauges
+	}
+	return c.fallback
+}
+
+// changed reports whether the value of the series, whose last value sent is
+// last, is to be sent.
+func (u *update) changed(s series, last, value float64) bool {
+	if u.p.detection == nil {
+		return value != last
+	}
+	var elapsed time.Duration
+	if sent, ok := u.p.last.sent[s.key]; ok {
+		elapsed = u.now.Sub(sent)
+	}
+	return u.p.detection.detector(s.name, u.gauge).Changed(last, value, elapsed)
+}
+
+// markSent records when the value of the series was sent, for change detectors.
+func (u *update) markSent(key string) {
+	if u.p.last.sent != nil {
+		u.p.last.sent[key] = u.now
+	}
+}
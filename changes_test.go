@@ -0,0 +1,119 @@
+package signalfx
+
+import (
+	"sort"
+	"time"
+
+	metrics "github.com/rcrowley/go-metrics"
+	. "gopkg.in/check.v1"
+)
+
+func (s *Zuite) TestChangeDetectors(c *C) {
+	for _, example := range []struct {
+		detector ChangeDetector
+		last     float64
+		value    float64
+		elapsed  time.Duration
+		changed  bool
+	}{
+		{AlwaysSend, 1, 1, time.Second, true},
+		{ExactChange, 1, 1, time.Second, false},
+		{ExactChange, 1, 1.5, time.Second, true},
+		{AbsoluteChange(10), 100, 109, time.Second, false},
+		{AbsoluteChange(10), 100, 90, time.Second, true},
+		{RelativeChange(0.01), 1000, 1009, time.Second, false},
+		{RelativeChange(0.01), 1000, 990, time.Second, true},
+		{RelativeChange(0.01), -1000, -1010, time.Second, true},
+		{RelativeChange(0.01), 0, 0.001, time.Second, true},
+		{RelativeChange(0.01), 0, 0, time.Second, false},
+		{RateOfChange(1), 100, 110, 20 * time.Second, false},
+		{RateOfChange(1), 100, 110, 10 * time.Second, true},
+		{RateOfChange(1), 100, 100, 0, false},
+		{RateOfChange(1), 100, 101, 0, true},
+	} {
+		c.Check(example.detector.Changed(example.last, example.value, example.elapsed), Equals, example.changed,
+			Commentf("last=%v value=%v elapsed=%s", example.last, example.value, example.elapsed))
+	}
+}
+
+func (s *Zuite) TestChangeDetection_rules(c *C) {
+	logger := &recordingLogger{}
+	p := newPublisher("", Options{
+		Logger:         logger,
+		ChangeDetector: AbsoluteChange(5),
+		ChangeDetectors: []ChangeDetectorRule{
+			{Pattern: "[", Detector: AlwaysSend},
+			{Pattern: "memory.*", Detector: RelativeChange(0.1)},
+			{Pattern: "memory.heap", Detector: AlwaysSend},
+		},
+	})
+	c.Assert(logger.lines, DeepEquals, []string{
+		`WARNING: ignoring change detector of invalid pattern "[": syntax error in pattern`,
+	})
+	p.last.gauges["memory.heap"] = 100
+	p.last.gauges["requests"] = 100
+
+	u := p.prepareUpdate()
+	u.appendIfGaugeChanged("memory.heap", 109)
+	u.appendIfGaugeChanged("requests", 104)
+	c.Assert(u.ds, HasLen, 0)
+	c.Assert(u.stats.Suppressed, Equals, 2)
+
+	u = p.prepareUpdate()
+	u.appendIfGaugeChanged("memory.heap", 110)
+	u.appendIfGaugeChanged("requests", 105)
+	c.Assert(u.ds, HasLen, 2)
+}
+
+func (s *Zuite) TestChangeDetection_elapsed(c *C) {
+	p, sender := newTestPublisher(Options{ChangeDetector: RateOfChange(1)})
+
+	r := metrics.NewRegistry()
+	gauge := metrics.GetOrRegisterGauge("queue", r)
+	gauge.Update(100)
+	c.Assert(p.single(r), IsNil)
+	c.Assert(sender.sent, HasLen, 1)
+	c.Assert(p.last.sent["queue"].IsZero(), Equals, false)
+
+	// Changing by 10 in 20s is slower than 1 per second.
+	p.last.sent["queue"] = time.Now().Add(-20 * time.Second)
+	gauge.Update(110)
+	c.Assert(p.single(r), IsNil)
+	c.Assert(sender.sent, HasLen, 1)
+
+	p.last.sent["queue"] = time.Now().Add(-5 * time.Second)
+	c.Assert(p.single(r), IsNil)
+	c.Assert(sender.sent, HasLen, 2)
+}
+
+func (s *Zuite) TestChangeDetection_gaugeChangeThreshold(c *C) {
+	p := newPublisher("", Options{
+		GaugeChangeThreshold: 0.01,
+		ChangeDetectors: []ChangeDetectorRule{
+			{Pattern: "queue", Detector: ExactChange},
+		},
+	})
+	p.last.gauges["memory"] = 1000
+	p.last.gauges["queue"] = 1000
+	p.last.counters["requests"] = 1000
+	p.last.gauges_f["latency.mean"] = 1000
+
+	r := metrics.NewRegistry()
+	metrics.GetOrRegisterGauge("memory", r).Update(1009)
+	metrics.GetOrRegisterGauge("queue", r).Update(1009)
+	metrics.GetOrRegisterCounter("requests", r).Inc(1009)
+
+	u := p.prepareUpdate()
+	u.collect(r)
+	var sent []string
+	for _, d := range u.ds {
+		sent = append(sent, d.Metric)
+	}
+	sort.Strings(sent)
+	c.Assert(sent, DeepEquals, []string{"queue", "requests"})
+
+	// Only the values of gauges are subject to the threshold.
+	u = p.prepareUpdate()
+	u.appendIfGaugeFChanged("latency.mean", 1009)
+	c.Assert(u.ds, HasLen, 1)
+}
@@ -0,0 +1,227 @@
+package signalfx
+
+import (
+	"fmt"
+	"hash/fnv"
+
+	"github.com/signalfx/golib/datapoint"
+)
+
+// CollisionPolicy is how series produced by distinct metrics are
+// disambiguated.
+type CollisionPolicy int
+
+const (
+	// CollisionDrop keeps the series of the first metric, and drops the
+	// others.
+	CollisionDrop CollisionPolicy = iota
+
+	// CollisionSuffix suffixes the name of the series of the other metrics
+	// with a hash of their registry and name.
+	CollisionSuffix
+
+	// CollisionDimension adds the CollisionDimensionKey dimension to the
+	// series of the other metrics, with their name and registry as value.
+	CollisionDimension
+
+	// CollisionSum sums the counters of all metrics into the series, e.g.
+	// to merge registries counting the same thing. Gauges are merged per
+	// the GaugeAggregations. Other series are those of the first metric,
+	// as with CollisionDrop.
+	CollisionSum
+
+	// CollisionError fails the flushes while metrics collide, so that
+	// collisions are caught in tests and strict deployments rather than
+	// silently resolved.
+	CollisionError
+)
+
+// CollisionDimensionKey is the dimension added by CollisionDimension.
+const CollisionDimensionKey = "source_metric"
+
+// origin identifies the registry metric a datapoint was derived from.
+type origin struct {
+	registry int
+	name     string
+}
+
+// suffix returns the suffix disambiguating the series of the origin.
+func (o origin) suffix() string {
+	h := fnv.New32a()
+	fmt.Fprintf(h, "%d:%s", o.registry, o.name)
+	return fmt.Sprintf("_%08x", h.Sum32())
+}
+
+func (o origin) String() string {
+	return fmt.Sprintf("%s#%d", o.name, o.registry)
+}
+
+// series identifies the series of a datapoint: its name and dimensions, and
+// its key in the caches.
+type series struct {
+	name string
+	dims map[string]string
+	key  string
+
+	// canonical identifies the series as sent once sanitized, and summed
+	// is set when the series is summed into the first metric's, see
+	// CollisionSum.
+	canonical string
+	summed    bool
+}
+
+// series returns the series of the named datapoint of the metric being
+// converted, and whether it is admitted. Series to be summed are dropped,
+// only counts are summed, see countSeries.
+func (u *update) series(name string) (series, bool) {
+	s, ok := u.countSeries(name)
+	return s, ok && !s.summed
+}
+
+// countSeries is series for counts, whose series may be summed. Datapoints
+// renamed to "" by the RenameFunc, or dropped by the NameFilters, are
+// dropped.
+func (u *update) countSeries(name string) (series, bool) {
+	if u.p.opt.RenameFunc != nil {
+		if name = u.p.opt.RenameFunc(name); name == "" {
+			return series{}, false
+		}
+	}
+	if !u.p.nameFilters.keep(name) {
+		return series{}, false
+	}
+	s := series{name: name, dims: u.dims, key: name + u.dimsKey}
+	if !u.claim(&s) {
+		return s, false
+	}
+	if s.summed {
+		return s, true
+	}
+	return s, u.admit(s.key)
+}
+
+// counterSum is the sum of the counts of the metrics colliding on a series,
+// see CollisionSum.
+type counterSum struct {
+	key   string
+	value int64
+
+	// d is the datapoint sent for the series, if any, and anchored is set
+	// when it is anchored at zero rather than sent with the sum.
+	d        *datapoint.Datapoint
+	anchored bool
+}
+
+// sum adds the count to the sum of its series, if summed, returning the
+// series and count to send, or false if the sum was already sent.
+func (u *update) sum(s series, count int64) (series, int64, *counterSum, bool) {
+	sum, ok := u.sums[s.canonical]
+	if !s.summed {
+		sum = &counterSum{key: s.key, value: count}
+		u.sums[s.canonical] = sum
+		return s, count, sum, true
+	}
+	if !ok || sum.anchored {
+		return s, 0, nil, false
+	}
+	sum.value += count
+	if sum.d != nil {
+		sum.d.Value = datapoint.NewIntValue(sum.value)
+		u.changes.counters[sum.key] = sum.value
+		return s, 0, nil, false
+	}
+	s.key = sum.key
+	return s, sum.value, sum, true
+}
+
+// claim records that the current source emits the series, as sent once
+// sanitized. If another source already emitted it during this update, the
+// collision is reported the first time it is seen, and the series is
+// disambiguated per the CollisionPolicy. It returns false if the datapoint
+// must be dropped rather than interleaved with the other source's.
+func (u *update) claim(s *series) bool {
+	canonical := s.name + encodeDimensions(legalDimensions(s.dims))
+	s.canonical = canonical
+	first, ok := u.emitted[canonical]
+	if !ok {
+		u.emitted[canonical] = u.source
+		return true
+	}
+	if first == u.source {
+		return true
+	}
+	if u.limited {
+		// Metrics whose dimension values were replaced are expected to
+		// collide, the first one is kept.
+		return false
+	}
+
+	resolution := "keeping the former"
+	switch u.p.opt.Collisions {
+	case CollisionSuffix:
+		s.name += u.source.suffix()
+		s.key = s.name + u.dimsKey
+		resolution = fmt.Sprintf("renaming the latter %q", s.name)
+	case CollisionDimension:
+		s.dims = mergeDimensions(s.dims, map[string]string{CollisionDimensionKey: u.source.String()})
+		s.key = s.name + encodeDimensions(s.dims)
+		resolution = fmt.Sprintf("adding dimension %s=%q to the latter", CollisionDimensionKey, u.source)
+	case CollisionSum:
+		resolution = "summing counters, keeping the former otherwise"
+	case CollisionError:
+		resolution = "failing the flush"
+	}
+	if _, reported := u.p.collisions[canonical]; !reported {
+		u.p.collisions[canonical] = struct{}{}
+		u.p.logf(levelWarning, "WARNING: metric %q is produced by both %q (registry #%d) and %q (registry #%d), %s",
+			canonical, first.name, first.registry, u.source.name, u.source.registry, resolution)
+	}
+	switch u.p.opt.Collisions {
+	case CollisionDrop:
+		return false
+	case CollisionSum:
+		s.summed = true
+		return true
+	case CollisionError:
+		if u.err == nil {
+			u.err = fmt.Errorf("metric %q is produced by both %q (registry #%d) and %q (registry #%d)",
+				canonical, first.name, first.registry, u.source.name, u.source.registry)
+		}
+		return false
+	}
+	disambiguated := s.name + encodeDimensions(legalDimensions(s.dims))
+	if other, ok := u.emitted[disambiguated]; ok && other != u.source {
+		return false
+	}
+	u.emitted[disambiguated] = u.source
+	return true
+}
+
+// settleSums drops the datapoints of summed series whose sum did not change,
+// as they were appended before the sum was complete.
+func (u *update) settleSums() {
+	unchanged := make(map[*datapoint.Datapoint]bool)
+	for _, sum := range u.sums {
+		if sum.d == nil || sum.anchored {
+			continue
+		}
+		s := series{name: sum.d.Metric, key: sum.key}
+		if last, ok := u.p.last.counters[sum.key]; ok && !u.changed(s, float64(last), float64(sum.value)) {
+			unchanged[sum.d] = true
+			delete(u.changes.counters, sum.key)
+		}
+	}
+	if len(unchanged) == 0 {
+		return
+	}
+	kept := u.ds[:0]
+	for _, d := range u.ds {
+		if unchanged[d] {
+			delete(u.keys, d)
+			continue
+		}
+		kept = append(kept, d)
+	}
+	u.ds = kept
+	u.stats.Suppressed += len(unchanged)
+}
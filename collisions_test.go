@@ -0,0 +1,159 @@
+package signalfx
+
+import (
+	"strings"
+
+	metrics "github.com/rcrowley/go-metrics"
+	. "gopkg.in/check.v1"
+)
+
+func (s *Zuite) TestClaim_collisionAcrossSources(c *C) {
+	p := newPublisher("", Options{})
+	u := p.prepareUpdate()
+
+	u.source = origin{registry: 0, name: "requests"}
+	u.appendIfCounterChanged("requests", 1)
+	u.source = origin{registry: 1, name: "requests"}
+	u.appendIfCounterChanged("requests", 2)
+
+	c.Assert(u.ds, HasLen, 1)
+	c.Assert(u.ds[0].Value.String(), Equals, "1")
+	c.Assert(p.collisions, HasLen, 1)
+}
+
+func (s *Zuite) TestClaim_collisionWithSubmetric(c *C) {
+	p := newPublisher("", Options{})
+	u := p.prepareUpdate()
+
+	h := metrics.NewHistogram(metrics.NewUniformSample(10))
+	h.Update(3)
+	u.source = origin{name: "latency"}
+	u.metricToDatapoints("latency", h)
+	u.source = origin{name: "latency.count"}
+	u.metricToDatapoints("latency.count", metrics.NewCounter())
+
+	c.Assert(u.changes.counters["latency.count"], Equals, int64(1))
+	c.Assert(p.collisions, HasLen, 1)
+}
+
+func (s *Zuite) TestClaim_collisionAfterSanitization(c *C) {
+	logger := &recordingLogger{}
+	p := newPublisher("", Options{Logger: logger})
+	u := p.prepareUpdate()
+
+	for i, name := range []string{"requests[host.name=a]", "requests[host_name=a]"} {
+		u.source = origin{name: name}
+		counter := metrics.NewCounter()
+		counter.Inc(int64(i))
+		u.metricToDatapoints(name, counter)
+	}
+
+	c.Assert(u.ds, HasLen, 1)
+	c.Assert(logger.lines, DeepEquals, []string{
+		`WARNING: renaming dimension "host.name" of requests to "host_name"`,
+		`WARNING: metric "requests[host_name=a]" is produced by both "requests[host.name=a]" (registry #0) and "requests[host_name=a]" (registry #0), keeping the former`,
+	})
+}
+
+func (s *Zuite) TestClaim_collisionSuffix(c *C) {
+	p := newPublisher("", Options{Collisions: CollisionSuffix})
+	u := p.prepareUpdate()
+
+	u.source = origin{registry: 0, name: "requests"}
+	u.appendIfCounterChanged("requests", 1)
+	u.source = origin{registry: 1, name: "requests"}
+	u.appendIfCounterChanged("requests", 2)
+
+	c.Assert(u.ds, HasLen, 2)
+	c.Assert(u.ds[1].Metric, Equals, "requests"+origin{registry: 1, name: "requests"}.suffix())
+	c.Assert(u.ds[1].Metric, Matches, "requests_[0-9a-f]{8}")
+	c.Assert(u.changes.counters, HasLen, 2)
+}
+
+func (s *Zuite) TestClaim_collisionDimension(c *C) {
+	p := newPublisher("", Options{Collisions: CollisionDimension})
+	u := p.prepareUpdate()
+
+	u.source = origin{registry: 0, name: "requests"}
+	u.appendIfCounterChanged("requests", 1)
+	u.source = origin{registry: 1, name: "requests"}
+	u.appendIfCounterChanged("requests", 2)
+
+	c.Assert(u.ds, HasLen, 2)
+	c.Assert(u.ds[1].Metric, Equals, "requests")
+	c.Assert(u.ds[1].Dimensions, DeepEquals, map[string]string{CollisionDimensionKey: "requests#1"})
+	c.Assert(u.changes.counters, HasLen, 2)
+}
+
+func (s *Zuite) TestClaim_collisionSum(c *C) {
+	p, sender := newTestPublisher(Options{Collisions: CollisionSum})
+
+	r1, r2 := metrics.NewRegistry(), metrics.NewRegistry()
+	p.opt.Registries = []metrics.Registry{r2}
+	metrics.GetOrRegisterCounter("requests", r1).Inc(1)
+	metrics.GetOrRegisterCounter("requests", r2).Inc(2)
+	metrics.GetOrRegisterGauge("queue", r1).Update(3)
+	metrics.GetOrRegisterGauge("queue", r2).Update(4)
+	h := metrics.GetOrRegisterHistogram("latency", r1, metrics.NewUniformSample(10))
+	h.Update(10)
+	metrics.GetOrRegisterHistogram("latency", r2, metrics.NewUniformSample(10)).Update(20)
+
+	values := func() map[string]string {
+		sent := make(map[string]string)
+		for _, d := range sender.sent {
+			sent[d.Metric] = d.Value.String()
+		}
+		sender.sent = nil
+		return sent
+	}
+
+	c.Assert(p.single(r1), IsNil)
+	sent := values()
+	c.Assert(sent["requests"], Equals, "3")
+	c.Assert(sent["queue"], Equals, "3")
+	c.Assert(sent["latency.count"], Equals, "2")
+	c.Assert(sent["latency.max"], Equals, "10")
+
+	// The sum is sent when only the second counter changed.
+	metrics.GetOrRegisterCounter("requests", r2).Inc(1)
+	c.Assert(p.single(r1), IsNil)
+	c.Assert(values(), DeepEquals, map[string]string{"requests": "4"})
+
+	// And suppressed when unchanged.
+	c.Assert(p.single(r1), IsNil)
+	c.Assert(values(), HasLen, 0)
+}
+
+func (s *Zuite) TestClaim_collisionError(c *C) {
+	p, sender := newTestPublisher(Options{Collisions: CollisionError})
+
+	r1, r2 := metrics.NewRegistry(), metrics.NewRegistry()
+	p.opt.Registries = []metrics.Registry{r2}
+	metrics.GetOrRegisterCounter("requests", r1).Inc(1)
+	metrics.GetOrRegisterCounter("requests", r2).Inc(2)
+
+	c.Assert(p.single(r1), ErrorMatches, `metric "requests" is produced by both "requests" \(registry #0\) and "requests" \(registry #1\)`)
+	c.Assert(sender.sent, HasLen, 0)
+	c.Assert(p.last.counters, HasLen, 0)
+
+	r2.Unregister("requests")
+	c.Assert(p.single(r1), IsNil)
+	c.Assert(sender.sent, HasLen, 1)
+}
+
+func (s *Zuite) TestRenameFunc(c *C) {
+	r := metrics.NewRegistry()
+	metrics.GetOrRegisterCounter("http.requests", r).Inc(1)
+	metrics.GetOrRegisterMeter("jobs", r).Mark(1)
+
+	batch := Collect(r, Options{
+		MetricPrefix: "svc.",
+		RenameFunc: func(name string) string {
+			if strings.HasSuffix(name, "-minute") {
+				return ""
+			}
+			return strings.Replace(name, ".", "_", -1)
+		},
+	})
+	c.Assert(batchNames(batch), DeepEquals, []string{"svc_http_requests", "svc_jobs_count", "svc_jobs_mean-rate"})
+}
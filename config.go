@@ -0,0 +1,123 @@
+package signalfx
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Config is the part of the options which can be changed while publishing,
+// through Admin.Reload, e.g. from a configuration file watched by the
+// signalfxconfig package. Fields left empty keep the publisher's options.
+//
+// Its JSON encoding spells durations as accepted by time.ParseDuration:
+//
+// 	{
+// 		"diff_frequency": "30s",
+// 		"exclude": ["go.runtime."],
+// 		"dimensions": {"team": "payments"},
+// 		"dpm_budget": 50000
+// 	}
+type Config struct {
+	// DiffFrequency and FullFrequency replace the options' frequencies.
+	DiffFrequency time.Duration
+	FullFrequency time.Duration
+
+	// Exclude drops the metrics whose registry name starts with one of the
+	// prefixes, on top of the options' Filters.
+	Exclude []string
+
+	// Dimensions are attached to all datapoints, on top of the detected
+	// resource dimensions and the options' DefaultDimensions.
+	Dimensions map[string]string
+
+	// DPMBudget replaces the options' budget of datapoints per minute.
+	DPMBudget int
+}
+
+type jsonConfig struct {
+	DiffFrequency string            `json:"diff_frequency"`
+	FullFrequency string            `json:"full_frequency"`
+	Exclude       []string          `json:"exclude"`
+	Dimensions    map[string]string `json:"dimensions"`
+	DPMBudget     int               `json:"dpm_budget"`
+}
+
+// UnmarshalJSON decodes the configuration, see Config.
+func (c *Config) UnmarshalJSON(data []byte) error {
+	var j jsonConfig
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	config := Config{
+		Exclude:    j.Exclude,
+		Dimensions: j.Dimensions,
+		DPMBudget:  j.DPMBudget,
+	}
+	var err error
+	if config.DiffFrequency, err = parseFrequency("diff_frequency", j.DiffFrequency); err != nil {
+		return err
+	}
+	if config.FullFrequency, err = parseFrequency("full_frequency", j.FullFrequency); err != nil {
+		return err
+	}
+	*c = config
+	return nil
+}
+
+func parseFrequency(field, value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %s", field, err)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("%s: %q is not positive", field, value)
+	}
+	return d, nil
+}
+
+// excludePrefixes drops the metrics whose name starts with one of the
+// prefixes.
+func excludePrefixes(prefixes []string) Filter {
+	return FilterFunc(func(name string, _ interface{}) bool {
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(name, prefix) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// reload applies the configuration on top of the options the publisher was
+// created with. It is called between flushes, so that a flush sees either
+// the previous configuration or the new one.
+func (p *publisher) reload(config Config) {
+	opt := p.base
+	if config.DiffFrequency > 0 {
+		opt.DiffFrequency = config.DiffFrequency
+	}
+	if config.FullFrequency > 0 {
+		opt.FullFrequency = config.FullFrequency
+	}
+	if len(config.Exclude) != 0 {
+		opt.Filters = append(append([]Filter(nil), opt.Filters...), excludePrefixes(config.Exclude))
+	}
+	if config.DPMBudget > 0 {
+		opt.DPMBudget = config.DPMBudget
+	}
+	if opt.DPMBudget != p.opt.DPMBudget {
+		p.budget = newBudgetTracker(opt)
+	}
+	p.defaultDims = mergeDimensions(p.detected, opt.DefaultDimensions, config.Dimensions)
+	if opt.PrometheusHandler != nil {
+		opt.PrometheusHandler.attach(opt.SelfMetrics, p.defaultDims)
+	}
+	p.opt = opt
+	p.logf(levelInfo, "reloaded configuration: diff_frequency=%s full_frequency=%s exclude=%v dimensions=%v dpm_budget=%d",
+		opt.DiffFrequency, opt.FullFrequency, config.Exclude, p.defaultDims, opt.DPMBudget)
+}
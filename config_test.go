@@ -0,0 +1,75 @@
+package signalfx
+
+import (
+	"encoding/json"
+	"time"
+
+	metrics "github.com/rcrowley/go-metrics"
+	. "gopkg.in/check.v1"
+)
+
+func (s *Zuite) TestConfig_UnmarshalJSON(c *C) {
+	var config Config
+	c.Assert(json.Unmarshal([]byte(`{
+		"diff_frequency": "30s",
+		"exclude": ["go."],
+		"dimensions": {"team": "payments"},
+		"dpm_budget": 1000
+	}`), &config), IsNil)
+	c.Assert(config, DeepEquals, Config{
+		DiffFrequency: 30 * time.Second,
+		Exclude:       []string{"go."},
+		Dimensions:    map[string]string{"team": "payments"},
+		DPMBudget:     1000,
+	})
+
+	c.Assert(json.Unmarshal([]byte(`{"full_frequency": "often"}`), &config), ErrorMatches, `full_frequency: .*`)
+	c.Assert(json.Unmarshal([]byte(`{"diff_frequency": "0s"}`), &config), ErrorMatches, `diff_frequency: "0s" is not positive`)
+}
+
+func (s *Zuite) TestReload(c *C) {
+	opt := Options{DPMBudget: 500}
+	setDefaults(&opt)
+	p, sender := newTestPublisher(opt)
+
+	r := metrics.NewRegistry()
+	metrics.GetOrRegisterCounter("go.goroutines", r).Inc(1)
+	metrics.GetOrRegisterCounter("requests", r).Inc(1)
+
+	p.reload(Config{
+		DiffFrequency: 30 * time.Second,
+		Exclude:       []string{"go."},
+		Dimensions:    map[string]string{"team": "payments"},
+	})
+	c.Assert(p.opt.DiffFrequency, Equals, 30*time.Second)
+	c.Assert(p.opt.FullFrequency, Equals, time.Minute)
+	c.Assert(p.budget.budget, Equals, 500)
+
+	c.Assert(p.single(r), IsNil)
+	c.Assert(sender.sent, HasLen, 1)
+	c.Assert(sender.sent[0].Metric, Equals, "requests")
+	c.Assert(sender.sent[0].Dimensions, DeepEquals, map[string]string{"team": "payments"})
+
+	// Reloading replaces the previous configuration.
+	p.reload(Config{DPMBudget: 1000})
+	c.Assert(p.opt.DiffFrequency, Equals, 15*time.Second)
+	c.Assert(p.opt.Filters, HasLen, 0)
+	c.Assert(p.defaultDims, HasLen, 0)
+	c.Assert(p.budget.budget, Equals, 1000)
+}
+
+func (s *Zuite) TestAdmin_Reload(c *C) {
+	admin := NewAdmin()
+	admin.Reload(Config{DPMBudget: 1000})
+	admin.Reload(Config{DPMBudget: 2000})
+	c.Assert(admin.Config().DPMBudget, Equals, 2000)
+
+	// Reloaded before the publisher started.
+	p := newPublisher("", Options{Admin: admin})
+	c.Assert(<-p.reloads, DeepEquals, Config{DPMBudget: 2000})
+
+	// Superseding a configuration not yet applied.
+	admin.Reload(Config{DPMBudget: 3000})
+	admin.Reload(Config{DPMBudget: 4000})
+	c.Assert(<-p.reloads, DeepEquals, Config{DPMBudget: 4000})
+}
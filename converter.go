@@ -0,0 +1,69 @@
+package signalfx
+
+import (
+	"sync"
+
+	"github.com/signalfx/golib/datapoint"
+)
+
+// Converter converts metrics of a custom type, which the publisher does not
+// know, into datapoints. It is called with the name of the metric as sent,
+// e.g. with the MetricPrefix, and returns false for metrics it does not
+// convert. The datapoints are sent with the dimensions of the metric in
+// addition to their own, renamed by the RenameFunc and filtered by the
+// NameFilters like those of other metrics, and are never suppressed. The
+// datapoints returned are not modified. The registries
+// of go-metrics only keep metrics of its types, including healthchecks, so
+// that custom metrics come from custom registries, or implement
+// metrics.Healthcheck.
+type Converter func(name string, metric interface{}) ([]*datapoint.Datapoint, bool)
+
+// RegisterConverter registers a converter of custom metrics, tried after the
+// converters already registered and Options.Converters. It is safe to call
+// while publishing.
+func (pub *Publisher) RegisterConverter(converter Converter) {
+	pub.p.converters.register(converter)
+}
+
+// converters are the registered converters, in order.
+type converters struct {
+	mu   sync.RWMutex
+	list []Converter
+}
+
+func (c *converters) register(converter Converter) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.list = append(c.list, converter)
+}
+
+// convert converts the metric with the first converter which does.
+func (c *converters) convert(name string, metric interface{}) ([]*datapoint.Datapoint, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, converter := range c.list {
+		if ds, ok := converter(name, metric); ok {
+			return ds, true
+		}
+	}
+	return nil, false
+}
+
+// appendConverted appends the datapoints of a custom metric, whose
+// dimensions are dims, less the default dimensions.
+func (u *update) appendConverted(dims map[string]string, ds []*datapoint.Datapoint) {
+	for _, d := range ds {
+		if d == nil {
+			continue
+		}
+		u.dimsKey = encodeDimensions(mergeDimensions(dims, d.Dimensions))
+		u.dims = mergeDimensions(u.p.defaultDims, dims, d.Dimensions)
+		s, ok := u.series(d.Metric)
+		if !ok {
+			continue
+		}
+		converted := *d
+		converted.Metric, converted.Dimensions = s.name, s.dims
+		u.append(s.key, &converted)
+	}
+}
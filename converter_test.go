@@ -0,0 +1,86 @@
+package signalfx
+
+import (
+	metrics "github.com/rcrowley/go-metrics"
+	"github.com/signalfx/golib/datapoint"
+	"github.com/signalfx/golib/sfxclient"
+	. "gopkg.in/check.v1"
+)
+
+// ratioMetric is a custom metric, which the publisher does not know.
+type ratioMetric struct {
+	hits, total int64
+}
+
+// customRegistry is a registry which also has custom metrics.
+type customRegistry struct {
+	metrics.Registry
+	custom map[string]interface{}
+}
+
+func (r customRegistry) Each(f func(string, interface{})) {
+	r.Registry.Each(f)
+	for name, i := range r.custom {
+		f(name, i)
+	}
+}
+
+func convertRatio(name string, metric interface{}) ([]*datapoint.Datapoint, bool) {
+	r, ok := metric.(*ratioMetric)
+	if !ok {
+		return nil, false
+	}
+	return []*datapoint.Datapoint{
+		sfxclient.GaugeF(name+".ratio", map[string]string{"kind": "hits"}, float64(r.hits)/float64(r.total)),
+	}, true
+}
+
+func (s *Zuite) TestConverters(c *C) {
+	sender := &fakeSender{}
+	r := customRegistry{metrics.NewRegistry(), map[string]interface{}{
+		NameWithDimensions("cache", map[string]string{"tier": "l1"}): &ratioMetric{hits: 1, total: 4},
+	}}
+	pub := New(r, "", Options{MetricPrefix: "app."})
+	pub.p.sender = sender
+	pub.RegisterConverter(func(string, interface{}) ([]*datapoint.Datapoint, bool) {
+		return nil, false
+	})
+	pub.RegisterConverter(convertRatio)
+
+	c.Assert(pub.p.single(r), IsNil)
+	point, ok := newBatch(sender.sent).Get("app.cache.ratio", map[string]string{"tier": "l1", "kind": "hits"})
+	c.Assert(ok, Equals, true)
+	c.Assert(point.Value, Equals, 0.25)
+}
+
+func (s *Zuite) TestConverters_options(c *C) {
+	r := customRegistry{metrics.NewRegistry(), map[string]interface{}{"cache": &ratioMetric{hits: 1, total: 2}}}
+	point, ok := Collect(r, Options{Converters: []Converter{convertRatio}}).Get("cache.ratio", map[string]string{"kind": "hits"})
+	c.Assert(ok, Equals, true)
+	c.Assert(point.Value, Equals, 0.5)
+}
+
+func (s *Zuite) TestConverters_renamedAndFiltered(c *C) {
+	dims := map[string]string{"kind": "hits"}
+	points := []*datapoint.Datapoint{
+		sfxclient.GaugeF("cache.ratio", dims, 0.5),
+		sfxclient.GaugeF("cache.misses", dims, 0.5),
+	}
+	convert := func(name string, metric interface{}) ([]*datapoint.Datapoint, bool) {
+		return points, true
+	}
+	r := customRegistry{metrics.NewRegistry(), map[string]interface{}{"cache": &ratioMetric{}}}
+	batch := Collect(r, Options{
+		Converters:        []Converter{convert},
+		RenameFunc:        func(name string) string { return "app." + name },
+		NameFilters:       []NameFilter{func(name string) bool { return name != "app.cache.misses" }},
+		DefaultDimensions: map[string]string{"host": "a"},
+	})
+	c.Assert(batch, HasLen, 1)
+	_, ok := batch.Get("app.cache.ratio", map[string]string{"kind": "hits", "host": "a"})
+	c.Assert(ok, Equals, true)
+
+	// The datapoints returned are not modified.
+	c.Assert(points[0].Metric, Equals, "cache.ratio")
+	c.Assert(points[0].Dimensions, DeepEquals, map[string]string{"kind": "hits"})
+}
@@ -0,0 +1,34 @@
+package signalfx
+
+import (
+	metrics "github.com/rcrowley/go-metrics"
+	. "gopkg.in/check.v1"
+)
+
+func (s *Zuite) TestCumulativeCounters(c *C) {
+	r := metrics.NewRegistry()
+	metrics.GetOrRegisterCounter("calls", r).Inc(3)
+	metrics.GetOrRegisterHistogram("sizes", r, metrics.NewUniformSample(10)).Update(5)
+	metrics.GetOrRegisterMeter("requests", r).Mark(2)
+
+	batch := Collect(r, Options{CumulativeCounters: true})
+	for name, typ := range map[string]PointType{
+		"calls":          PointCumulativeCounter,
+		"sizes.count":    PointCumulativeCounter,
+		"sizes.max":      PointCounter,
+		"requests.count": PointCumulativeCounter,
+	} {
+		point, ok := batch.Get(name, nil)
+		c.Assert(ok, Equals, true, Commentf(name))
+		c.Assert(point.Type, Equals, typ, Commentf(name))
+	}
+	point, _ := batch.Get("calls", nil)
+	c.Assert(point.Value, Equals, 3.0)
+
+	// Interval counts are not cumulative.
+	batch = Collect(r, Options{CumulativeCounters: true, IntervalCounts: true})
+	point, _ = batch.Get("requests.count", nil)
+	c.Assert(point.Type, Equals, PointCounter)
+	point, _ = batch.Get("calls", nil)
+	c.Assert(point.Type, Equals, PointCumulativeCounter)
+}
@@ -0,0 +1,29 @@
+package signalfx
+
+import (
+	"strconv"
+
+	"github.com/signalfx/golib/datapoint"
+)
+
+// dedup removes the datapoints with the same name, dimensions and timestamp
+// as an earlier one, so that no point is delivered twice, returning the
+// remaining datapoints, the number of datapoints removed, and how many of
+// those had a different value than the datapoint kept.
+func dedup(ds []*datapoint.Datapoint) ([]*datapoint.Datapoint, int, int) {
+	seen := make(map[string]*datapoint.Datapoint, len(ds))
+	kept := ds[:0]
+	conflicts := 0
+	for _, d := range ds {
+		key := d.Metric + encodeDimensions(d.Dimensions) + "@" + strconv.FormatInt(d.Timestamp.UnixNano(), 10)
+		if first, ok := seen[key]; ok {
+			if first.Value.String() != d.Value.String() {
+				conflicts++
+			}
+			continue
+		}
+		seen[key] = d
+		kept = append(kept, d)
+	}
+	return kept, len(ds) - len(kept), conflicts
+}
@@ -0,0 +1,31 @@
+package signalfx
+
+import (
+	"time"
+
+	"github.com/signalfx/golib/datapoint"
+	"github.com/signalfx/golib/sfxclient"
+	. "gopkg.in/check.v1"
+)
+
+func (s *Zuite) TestDedup(c *C) {
+	now := time.Now()
+	at := func(d *datapoint.Datapoint, t time.Time) *datapoint.Datapoint {
+		d.Timestamp = t
+		return d
+	}
+	ds := []*datapoint.Datapoint{
+		at(sfxclient.Counter("requests", map[string]string{"env": "prod"}, 1), now),
+		at(sfxclient.Counter("requests", map[string]string{"env": "prod"}, 1), now),
+		at(sfxclient.Counter("requests", map[string]string{"env": "dev"}, 1), now),
+		at(sfxclient.Counter("requests", map[string]string{"env": "prod"}, 2), now.Add(time.Second)),
+		sfxclient.Gauge("queue", nil, 1),
+		sfxclient.Gauge("queue", nil, 2),
+	}
+	kept, removed, conflicts := dedup(ds)
+	c.Assert(removed, Equals, 2)
+	c.Assert(conflicts, Equals, 1)
+	c.Assert(kept, HasLen, 4)
+	c.Assert(kept[0], Equals, ds[0])
+	c.Assert(kept[3].Value.String(), Equals, "1")
+}
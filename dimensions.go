@@ -0,0 +1,136 @@
+package signalfx
+
+import (
+	"sort"
+	"strings"
+)
+
+// NameWithDimensions encodes dimensions into a metric name, such that
+// metrics registered under the resulting name are published with these
+// dimensions. For instance
+//
+// 	metrics.GetOrRegisterCounter(signalfx.NameWithDimensions("requests", map[string]string{
+// 		"route": "/checkout",
+// 	}), metrics.DefaultRegistry)
+//
+// is published as the counter "requests" with dimension route=/checkout.
+// Dimension keys and values must not contain '[', ']', ',' or '='.
+func NameWithDimensions(name string, dims map[string]string) string {
+	base, existing := parseName(name)
+	return base + encodeDimensions(mergeDimensions(existing, dims))
+}
+
+// parseName splits a name produced by NameWithDimensions into the metric
+// name and its dimensions. Names ending in brackets holding no dimension,
+// such as "pool[primary]", are left unchanged.
+func parseName(name string) (string, map[string]string) {
+	if !strings.HasSuffix(name, "]") {
+		return name, nil
+	}
+	start := strings.LastIndex(name, "[")
+	if start < 0 {
+		return name, nil
+	}
+	dims := make(map[string]string)
+	for _, pair := range strings.Split(name[start+1:len(name)-1], ",") {
+		if kv := strings.SplitN(pair, "=", 2); len(kv) == 2 && kv[0] != "" {
+			dims[kv[0]] = kv[1]
+		}
+	}
+	if len(dims) == 0 {
+		return name, nil
+	}
+	return name[:start], dims
+}
+
+// encodeDimensions produces the canonical encoding of dimensions, with keys
+// sorted, as used in names and series keys.
+func encodeDimensions(dims map[string]string) string {
+	if len(dims) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(dims))
+	for key := range dims {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, key+"="+dims[key])
+	}
+	return "[" + strings.Join(pairs, ",") + "]"
+}
+
+// mergeDimensions returns the union of the dimensions, later ones taking
+// precedence over earlier ones.
+func mergeDimensions(all ...map[string]string) map[string]string {
+	var merged map[string]string
+	for _, dims := range all {
+		for key, value := range dims {
+			if merged == nil {
+				merged = make(map[string]string)
+			}
+			merged[key] = value
+		}
+	}
+	return merged
+}
+
+// DimensionProvider provides dynamic dimensions, such as the current leader
+// status or a feature-flag cohort, attached to all datapoints. Providers are
+// evaluated once per flush.
+type DimensionProvider interface {
+	Dimensions() map[string]string
+}
+
+// DimensionProviderFunc adapts a function to a DimensionProvider.
+type DimensionProviderFunc func() map[string]string
+
+// Dimensions calls f.
+func (f DimensionProviderFunc) Dimensions() map[string]string {
+	return f()
+}
+
+// provideDimensions evaluates the providers, dimensions of later providers
+// overriding those of earlier ones.
+func provideDimensions(providers []DimensionProvider) map[string]string {
+	var dims map[string]string
+	for _, provider := range providers {
+		dims = mergeDimensions(dims, provider.Dimensions())
+	}
+	return dims
+}
+
+// DimensionTemplate computes dimensions of a metric at flush time, from its
+// name and dimensions, for enrichment not known when metrics are
+// registered. The dimensions of the metric take precedence over those
+// computed.
+type DimensionTemplate func(name string, dims map[string]string) map[string]string
+
+// PrefixDimension returns a DimensionTemplate setting the dimension key to
+// the value of the longest prefix of the metric's name in values, if any,
+// e.g. to derive a tier from a lookup keyed by prefix:
+//
+// 	signalfx.PrefixDimension("tier", map[string]string{"checkout.": "critical"})
+func PrefixDimension(key string, values map[string]string) DimensionTemplate {
+	prefixes := newOwners(values)
+	return func(name string, _ map[string]string) map[string]string {
+		if value := prefixes.owner(name); value != "" {
+			return map[string]string{key: value}
+		}
+		return nil
+	}
+}
+
+// templateDimensions adds the dimensions computed by the templates to those
+// of the metric.
+func templateDimensions(templates []DimensionTemplate, name string, dims map[string]string) map[string]string {
+	var computed map[string]string
+	for _, template := range templates {
+		computed = mergeDimensions(computed, template(name, dims))
+	}
+	if computed == nil {
+		return dims
+	}
+	return mergeDimensions(computed, dims)
+}
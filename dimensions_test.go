@@ -0,0 +1,113 @@
+package signalfx
+
+import (
+	metrics "github.com/rcrowley/go-metrics"
+	. "gopkg.in/check.v1"
+)
+
+func (s *Zuite) TestNameWithDimensions(c *C) {
+	c.Assert(NameWithDimensions("requests", nil), Equals, "requests")
+	c.Assert(NameWithDimensions("requests", map[string]string{"b": "2", "a": "1"}), Equals, "requests[a=1,b=2]")
+	c.Assert(NameWithDimensions("requests[a=1]", map[string]string{"a": "3", "b": "2"}), Equals, "requests[a=3,b=2]")
+}
+
+func (s *Zuite) TestParseName(c *C) {
+	name, dims := parseName("requests")
+	c.Assert(name, Equals, "requests")
+	c.Assert(dims, IsNil)
+
+	name, dims = parseName("requests[a=1,b=2]")
+	c.Assert(name, Equals, "requests")
+	c.Assert(dims, DeepEquals, map[string]string{"a": "1", "b": "2"})
+
+	name, dims = parseName("requests[]")
+	c.Assert(name, Equals, "requests[]")
+	c.Assert(dims, IsNil)
+
+	name, dims = parseName("pool[primary]")
+	c.Assert(name, Equals, "pool[primary]")
+	c.Assert(dims, IsNil)
+}
+
+func (s *Zuite) TestAppendIfCounterChanged_dimensions(c *C) {
+	p := newPublisher("", Options{})
+	p.last.counters["requests"] = 5
+
+	u := p.prepareUpdate()
+	u.dims, u.dimsKey = map[string]string{"a": "1"}, "[a=1]"
+	u.appendIfCounterChanged("requests", 5)
+
+	c.Assert(u.ds, HasLen, 1)
+	c.Assert(u.ds[0].Metric, Equals, "requests")
+	c.Assert(u.ds[0].Dimensions, DeepEquals, map[string]string{"a": "1"})
+	c.Assert(u.changes.counters["requests[a=1]"], Equals, int64(5))
+}
+
+func (s *Zuite) TestMetricToDatapoints_dimensionProviders(c *C) {
+	leader := "true"
+	p := newPublisher("", Options{
+		DimensionProviders: []DimensionProvider{
+			DimensionProviderFunc(func() map[string]string {
+				return map[string]string{"leader": leader}
+			}),
+		},
+	})
+	p.defaultDims = map[string]string{"host_name": "a"}
+
+	u := p.prepareUpdate()
+	u.metricToDatapoints("jobs", metrics.NewGauge())
+
+	c.Assert(u.ds, HasLen, 1)
+	c.Assert(u.ds[0].Dimensions, DeepEquals, map[string]string{"host_name": "a", "leader": "true"})
+	c.Assert(u.changes.gauges["jobs[leader=true]"], Equals, int64(0))
+
+	// A change of provided dimensions starts a new series.
+	p.last.gauges["jobs[leader=true]"] = 0
+	leader = "false"
+	u = p.prepareUpdate()
+	u.metricToDatapoints("jobs", metrics.NewGauge())
+
+	c.Assert(u.ds, HasLen, 1)
+	c.Assert(u.ds[0].Dimensions["leader"], Equals, "false")
+}
+
+func (s *Zuite) TestDefaultDimensions(c *C) {
+	r := metrics.NewRegistry()
+	metrics.GetOrRegisterCounter("requests[env=staging]", r).Inc(1)
+	metrics.GetOrRegisterHistogram("latency", r, metrics.NewUniformSample(10)).Update(3)
+
+	batch := Collect(r, Options{DefaultDimensions: map[string]string{"service": "api", "env": "prod"}})
+
+	point, ok := batch.Get("requests", map[string]string{"service": "api", "env": "staging"})
+	c.Assert(ok, Equals, true)
+	c.Assert(point.Value, Equals, float64(1))
+	for _, point := range batch {
+		c.Assert(point.Dims["service"], Equals, "api")
+	}
+	_, ok = batch.Get("latency.max", map[string]string{"service": "api", "env": "prod"})
+	c.Assert(ok, Equals, true)
+}
+
+func (s *Zuite) TestDimensionTemplates(c *C) {
+	r := metrics.NewRegistry()
+	metrics.GetOrRegisterGauge("checkout.latency", r).Update(1)
+	metrics.GetOrRegisterGauge("checkout.errors[tier=gold]", r).Update(2)
+	metrics.GetOrRegisterGauge("search.latency[region=eu]", r).Update(3)
+
+	batch := Collect(r, Options{DimensionTemplates: []DimensionTemplate{
+		PrefixDimension("tier", map[string]string{"checkout.": "critical"}),
+		func(name string, dims map[string]string) map[string]string {
+			if dims["region"] == "" {
+				return nil
+			}
+			return map[string]string{"geo": "region-" + dims["region"]}
+		},
+	}})
+
+	_, ok := batch.Get("checkout.latency", map[string]string{"tier": "critical"})
+	c.Assert(ok, Equals, true)
+	_, ok = batch.Get("checkout.errors", map[string]string{"tier": "gold"})
+	c.Assert(ok, Equals, true)
+	_, ok = batch.Get("search.latency", map[string]string{"region": "eu", "geo": "region-eu"})
+	c.Assert(ok, Equals, true)
+}
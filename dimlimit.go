@@ -0,0 +1,51 @@
+package signalfx
+
+// OtherDimensionValue replaces the values of a dimension beyond its limit of
+// distinct values, see Options.MaxDimensionValues.
+const OtherDimensionValue = "__other__"
+
+// dimensionLimiter caps the number of distinct values of dimensions.
+type dimensionLimiter struct {
+	max    map[string]int
+	values map[string]map[string]struct{}
+}
+
+func newDimensionLimiter(max map[string]int) *dimensionLimiter {
+	return &dimensionLimiter{max: max, values: make(map[string]map[string]struct{})}
+}
+
+// limit returns the dimensions with the values beyond the limit of their key
+// replaced by OtherDimensionValue, the number of values replaced, and the
+// keys which overflowed for the first time. The dimensions are copied if
+// modified.
+func (l *dimensionLimiter) limit(dims map[string]string) (limited map[string]string, replaced int, overflowed []string) {
+	limited = dims
+	for key, value := range dims {
+		max, ok := l.max[key]
+		if !ok {
+			continue
+		}
+		values := l.values[key]
+		if values == nil {
+			values = make(map[string]struct{})
+			l.values[key] = values
+		}
+		if _, ok := values[value]; ok {
+			continue
+		}
+		if len(values) < max {
+			values[value] = struct{}{}
+			continue
+		}
+		if _, ok := values[OtherDimensionValue]; !ok {
+			values[OtherDimensionValue] = struct{}{}
+			overflowed = append(overflowed, key)
+		}
+		if replaced == 0 {
+			limited = mergeDimensions(dims)
+		}
+		limited[key] = OtherDimensionValue
+		replaced++
+	}
+	return limited, replaced, overflowed
+}
@@ -0,0 +1,47 @@
+package signalfx
+
+import (
+	metrics "github.com/rcrowley/go-metrics"
+	. "gopkg.in/check.v1"
+)
+
+func (s *Zuite) TestDimensionLimiter(c *C) {
+	l := newDimensionLimiter(map[string]int{"endpoint": 2})
+
+	for _, endpoint := range []string{"/a", "/b", "/a"} {
+		dims := map[string]string{"endpoint": endpoint, "env": "prod"}
+		limited, replaced, overflowed := l.limit(dims)
+		c.Assert(limited, DeepEquals, dims)
+		c.Assert(replaced, Equals, 0)
+		c.Assert(overflowed, HasLen, 0)
+	}
+
+	dims := map[string]string{"endpoint": "/c", "env": "prod"}
+	limited, replaced, overflowed := l.limit(dims)
+	c.Assert(limited, DeepEquals, map[string]string{"endpoint": OtherDimensionValue, "env": "prod"})
+	c.Assert(dims["endpoint"], Equals, "/c")
+	c.Assert(replaced, Equals, 1)
+	c.Assert(overflowed, DeepEquals, []string{"endpoint"})
+
+	_, replaced, overflowed = l.limit(map[string]string{"endpoint": "/d"})
+	c.Assert(replaced, Equals, 1)
+	c.Assert(overflowed, HasLen, 0)
+}
+
+func (s *Zuite) TestMetricToDatapoints_maxDimensionValues(c *C) {
+	logger := &recordingLogger{}
+	p := newPublisher("", Options{Logger: logger, MaxDimensionValues: map[string]int{"user": 1}})
+	u := p.prepareUpdate()
+
+	for _, user := range []string{"alice", "bob", "carol"} {
+		u.source = origin{name: user}
+		u.metricToDatapoints(NameWithDimensions("logins", map[string]string{"user": user}), metrics.NewCounter())
+	}
+
+	c.Assert(u.ds, HasLen, 2)
+	c.Assert(u.ds[1].Dimensions, DeepEquals, map[string]string{"user": OtherDimensionValue})
+	c.Assert(u.stats.Overflowed, Equals, 2)
+	c.Assert(logger.lines, DeepEquals, []string{
+		`WARNING: dimension "user" has more than 1 values, replacing new values with __other__`,
+	})
+}
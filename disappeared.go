@@ -0,0 +1,51 @@
+package signalfx
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/signalfx/golib/event"
+)
+
+// DisappearedEventType is the type of the SignalFX events sent when a
+// published metric is no longer in the registry, see DisappearedEvents.
+const DisappearedEventType = "signalfx.metric_disappeared"
+
+// checkDisappeared reports the metrics published by the previous collection
+// which are no longer in the registries.
+func (p *publisher) checkDisappeared(u *update) {
+	if u.present == nil {
+		return
+	}
+	previous := p.present
+	p.present = u.present
+	if previous == nil {
+		return
+	}
+	var disappeared []string
+	for name := range previous {
+		if _, ok := u.present[name]; !ok {
+			disappeared = append(disappeared, name)
+		}
+	}
+	if len(disappeared) == 0 {
+		return
+	}
+	sort.Strings(disappeared)
+	for _, name := range disappeared {
+		p.logf(levelWarning, "WARNING: metric %q disappeared from the registry", name)
+	}
+	if !p.opt.DisappearedEvents || p.sender == nil {
+		return
+	}
+	now := time.Now()
+	events := make([]*event.Event, 0, len(disappeared))
+	for _, name := range disappeared {
+		dims := mergeDimensions(p.defaultDims, map[string]string{"metric": name})
+		events = append(events, event.New(DisappearedEventType, event.USERDEFINED, dims, now))
+	}
+	if err := p.sender.AddEvents(context.Background(), events); err != nil {
+		p.logf(levelWarning, "WARNING: unable to send %s events: %s", DisappearedEventType, err)
+	}
+}
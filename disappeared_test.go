@@ -0,0 +1,36 @@
+package signalfx
+
+import (
+	metrics "github.com/rcrowley/go-metrics"
+	. "gopkg.in/check.v1"
+)
+
+func (s *Zuite) TestCheckDisappeared(c *C) {
+	logger := &recordingLogger{}
+	sender := &eventSender{}
+	p := newPublisher("", Options{ReportDisappeared: true, DisappearedEvents: true, Logger: logger})
+	p.sender = sender
+
+	r := metrics.NewRegistry()
+	metrics.GetOrRegisterCounter("requests", r).Inc(1)
+	metrics.GetOrRegisterGauge("queue[shard=1]", r).Update(1)
+	metrics.GetOrRegisterGauge("queue[shard=2]", r).Update(1)
+
+	c.Assert(p.single(r), IsNil)
+	c.Assert(logger.lines, HasLen, 0)
+
+	r.Unregister("requests")
+	r.Unregister("queue[shard=2]")
+	c.Assert(p.single(r), IsNil)
+	c.Assert(logger.lines, DeepEquals, []string{
+		`WARNING: metric "queue[shard=2]" disappeared from the registry`,
+		`WARNING: metric "requests" disappeared from the registry`,
+	})
+	c.Assert(sender.events, HasLen, 2)
+	c.Assert(sender.events[0].EventType, Equals, DisappearedEventType)
+	c.Assert(sender.events[0].Dimensions, DeepEquals, map[string]string{"metric": "queue[shard=2]"})
+
+	// Disappeared metrics are only reported once.
+	c.Assert(p.single(r), IsNil)
+	c.Assert(logger.lines, HasLen, 2)
+}
@@ -0,0 +1,91 @@
+package signalfx
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/signalfx/golib/datapoint"
+	"github.com/signalfx/golib/event"
+	"github.com/signalfx/golib/sfxclient"
+)
+
+// Encoder serializes the datapoints of requests, for ingest services with
+// bespoke wire formats, such as internal gateways. The scheduling, diffing
+// and error handling of the publisher are kept, only the body of requests
+// changes.
+type Encoder interface {
+	// ContentType is the content type of the encoded bodies.
+	ContentType() string
+
+	// Encode writes the points to the body of a request.
+	Encode(w io.Writer, points []Point) error
+}
+
+// JSONEncoder encodes datapoints as the JSON accepted by the SignalFX
+// ingest API, grouped by metric type.
+var JSONEncoder Encoder = jsonEncoder{}
+
+type jsonEncoder struct{}
+
+func (jsonEncoder) ContentType() string {
+	return "application/json"
+}
+
+type jsonPoint struct {
+	Metric     string            `json:"metric"`
+	Value      float64           `json:"value"`
+	Dimensions map[string]string `json:"dimensions,omitempty"`
+	Timestamp  int64             `json:"timestamp,omitempty"`
+}
+
+func (jsonEncoder) Encode(w io.Writer, points []Point) error {
+	byType := make(map[PointType][]jsonPoint)
+	for _, p := range points {
+		j := jsonPoint{Metric: p.Name, Value: p.Value, Dimensions: p.Dims}
+		if !p.Time.IsZero() {
+			j.Timestamp = p.Time.UnixNano() / 1e6
+		}
+		byType[p.Type] = append(byType[p.Type], j)
+	}
+	return json.NewEncoder(w).Encode(byType)
+}
+
+// encodingSender sends datapoints encoded by the encoder, through the
+// client's HTTP client, to its datapoint endpoint and with its token. Events
+// are sent by the client.
+type encodingSender struct {
+	client  *sfxclient.HTTPSink
+	encoder Encoder
+}
+
+func (s *encodingSender) AddDatapoints(ctx context.Context, points []*datapoint.Datapoint) error {
+	var body bytes.Buffer
+	if err := s.encoder.Encode(&body, newBatch(points)); err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.client.DatapointEndpoint, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", s.encoder.ContentType())
+	req.Header.Set(sfxclient.TokenHeaderName, s.client.AuthToken)
+	req.Header.Set("User-Agent", s.client.UserAgent)
+	resp, err := s.client.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return sfxclient.SFXAPIError{StatusCode: resp.StatusCode, ResponseBody: string(respBody)}
+	}
+	io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+func (s *encodingSender) AddEvents(ctx context.Context, events []*event.Event) error {
+	return s.client.AddEvents(ctx, events)
+}
@@ -0,0 +1,49 @@
+package signalfx
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	metrics "github.com/rcrowley/go-metrics"
+	"github.com/signalfx/golib/sfxclient"
+	. "gopkg.in/check.v1"
+)
+
+func (s *Zuite) TestJSONEncoder(c *C) {
+	var buf bytes.Buffer
+	c.Assert(JSONEncoder.Encode(&buf, []Point{
+		{Name: "requests", Type: PointCounter, Value: 3, Dims: map[string]string{"env": "prod"}},
+		{Name: "queue", Type: PointGauge, Value: 1.5, Time: time.Unix(1, 0)},
+	}), IsNil)
+	c.Assert(buf.String(), Equals, `{"counter":[{"metric":"requests","value":3,"dimensions":{"env":"prod"}}],"gauge":[{"metric":"queue","value":1.5,"timestamp":1000}]}`+"\n")
+}
+
+func (s *Zuite) TestEncodingSender(c *C) {
+	var bodies []string
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		c.Check(req.Header.Get("Content-Type"), Equals, "application/json")
+		c.Check(req.Header.Get(sfxclient.TokenHeaderName), Equals, "token")
+		body, _ := io.ReadAll(req.Body)
+		bodies = append(bodies, string(body))
+		w.WriteHeader(status)
+		w.Write([]byte("nope"))
+	}))
+	defer server.Close()
+
+	p := newPublisher("token", Options{Encoder: JSONEncoder})
+	c.Assert(p.connect(), IsNil)
+	p.client.DatapointEndpoint = server.URL
+
+	r := metrics.NewRegistry()
+	metrics.GetOrRegisterGauge("queue", r).Update(2)
+	c.Assert(p.single(r), IsNil)
+	c.Assert(bodies, DeepEquals, []string{`{"gauge":[{"metric":"queue","value":2}]}` + "\n"})
+
+	status = http.StatusServiceUnavailable
+	metrics.GetOrRegisterGauge("queue", r).Update(3)
+	c.Assert(p.single(r), DeepEquals, sfxclient.SFXAPIError{StatusCode: http.StatusServiceUnavailable, ResponseBody: "nope"})
+}
@@ -0,0 +1,134 @@
+package signalfx
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	datapointPath = "/v2/datapoint"
+	eventPath     = "/v2/event"
+)
+
+// realmIngest returns the ingest endpoint of the realm, e.g. "us1".
+func realmIngest(realm string) string {
+	return "https://ingest." + realm + ".signalfx.com"
+}
+
+// endpointFailover keeps the ingest endpoint in use, failing over to the next
+// one after sustained failures, and probing the preferred ones for recovery.
+type endpointFailover struct {
+	endpoints []string
+	after     int
+	interval  time.Duration
+
+	// active is the index of the endpoint in use, failures the number of
+	// consecutive failed flushes against it, and probed the time at which
+	// the preferred endpoints were last probed.
+	active   int
+	failures int
+	probed   time.Time
+}
+
+func newEndpointFailover(opt Options) *endpointFailover {
+	f := &endpointFailover{
+		after:    opt.EndpointFailoverAfter,
+		interval: opt.EndpointProbeInterval,
+	}
+	for _, endpoint := range opt.Endpoints {
+		f.endpoints = append(f.endpoints, strings.TrimSuffix(endpoint, "/"))
+	}
+	if f.after <= 0 {
+		f.after = 3
+	}
+	if f.interval <= 0 {
+		f.interval = time.Minute
+	}
+	return f
+}
+
+// endpointFailure reports whether the error is caused by the endpoint,
+// rather than by what was sent to it.
+func endpointFailure(err error) bool {
+	if status, ok := apiStatus(err); ok {
+		return status >= http.StatusInternalServerError
+	}
+	return cause(err) != context.Canceled
+}
+
+// useEndpoint points the client at the active endpoint.
+func (p *publisher) useEndpoint() {
+	if p.endpoints == nil || p.client == nil {
+		return
+	}
+	base := p.endpoints.endpoints[p.endpoints.active]
+	p.client.DatapointEndpoint = base + datapointPath
+	p.client.EventEndpoint = base + eventPath
+}
+
+// checkEndpoint records the outcome of a flush against the active endpoint.
+// It fails over to the next endpoint after sustained failures and, while
+// not on the primary endpoint, periodically probes the preferred ones to
+// return to the first one that recovered.
+func (p *publisher) checkEndpoint(err error, now time.Time) {
+	f := p.endpoints
+	if f == nil || p.client == nil {
+		return
+	}
+	if err != nil {
+		if !endpointFailure(err) {
+			return
+		}
+		f.failures++
+		if f.failures < f.after || len(f.endpoints) < 2 {
+			return
+		}
+		from := f.active
+		f.active = (f.active + 1) % len(f.endpoints)
+		f.failures = 0
+		f.probed = now
+		p.useEndpoint()
+		p.logf(levelWarning, "WARNING: %d consecutive failures sending to %s, failing over to %s",
+			f.after, f.endpoints[from], f.endpoints[f.active])
+		return
+	}
+	f.failures = 0
+	if f.active == 0 || now.Sub(f.probed) < f.interval {
+		return
+	}
+	f.probed = now
+	for i := 0; i < f.active; i++ {
+		if err := p.probe(f.endpoints[i]); err != nil {
+			p.logf(levelVerbose, "probing %s: %s", f.endpoints[i], err)
+			continue
+		}
+		p.logf(levelWarning, "%s recovered, failing back from %s", f.endpoints[i], f.endpoints[f.active])
+		f.active = i
+		p.useEndpoint()
+		return
+	}
+}
+
+// probe checks that the endpoint accepts datapoints, by sending none.
+func (p *publisher) probe(base string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, base+datapointPath, strings.NewReader("{}"))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-SF-Token", p.authToken)
+	resp, err := p.client.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("invalid status code %d", resp.StatusCode)
+	}
+	return nil
+}
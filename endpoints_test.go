@@ -0,0 +1,78 @@
+package signalfx
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/signalfx/golib/datapoint"
+	"github.com/signalfx/golib/sfxclient"
+	. "gopkg.in/check.v1"
+)
+
+func (s *Zuite) TestEndpointFailure(c *C) {
+	c.Assert(endpointFailure(errors.New("connection refused")), Equals, true)
+	c.Assert(endpointFailure(sfxclient.SFXAPIError{StatusCode: http.StatusServiceUnavailable}), Equals, true)
+	c.Assert(endpointFailure(sfxclient.SFXAPIError{StatusCode: http.StatusBadRequest}), Equals, false)
+	c.Assert(endpointFailure(context.Canceled), Equals, false)
+
+	// The sink annotates the errors of the context.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := sfxclient.NewHTTPSink().AddDatapoints(ctx, []*datapoint.Datapoint{sfxclient.Gauge("requests", nil, 1)})
+	c.Assert(err, NotNil)
+	c.Assert(endpointFailure(err), Equals, false)
+}
+
+func (s *Zuite) TestCheckEndpoint(c *C) {
+	healthy := false
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		c.Check(req.URL.Path, Equals, "/v2/datapoint")
+		c.Check(req.Header.Get("X-SF-Token"), Equals, "token")
+		if !healthy {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`"OK"`))
+	}))
+	defer primary.Close()
+
+	p := newPublisher("token", Options{
+		Endpoints:             []string{primary.URL + "/", "https://secondary.example.com"},
+		EndpointFailoverAfter: 2,
+	})
+	c.Assert(p.connect(), IsNil)
+	c.Assert(p.client.DatapointEndpoint, Equals, primary.URL+"/v2/datapoint")
+	c.Assert(p.client.EventEndpoint, Equals, primary.URL+"/v2/event")
+
+	now := time.Now()
+	unreachable := errors.New("unreachable")
+
+	// Failures need to be sustained, and caused by the endpoint.
+	p.checkEndpoint(unreachable, now)
+	p.checkEndpoint(sfxclient.SFXAPIError{StatusCode: http.StatusBadRequest}, now)
+	c.Assert(p.endpoints.active, Equals, 0)
+	p.checkEndpoint(unreachable, now)
+	c.Assert(p.endpoints.active, Equals, 1)
+	c.Assert(p.client.DatapointEndpoint, Equals, "https://secondary.example.com/v2/datapoint")
+
+	// Reconnecting keeps the active endpoint.
+	p.disconnect()
+	c.Assert(p.connect(), IsNil)
+	c.Assert(p.client.DatapointEndpoint, Equals, "https://secondary.example.com/v2/datapoint")
+
+	// The primary is probed once per interval, until it recovers.
+	p.checkEndpoint(nil, now.Add(30*time.Second))
+	c.Assert(p.endpoints.probed, Equals, now)
+	p.checkEndpoint(nil, now.Add(time.Minute))
+	c.Assert(p.endpoints.active, Equals, 1)
+
+	healthy = true
+	p.checkEndpoint(nil, now.Add(90*time.Second))
+	c.Assert(p.endpoints.active, Equals, 1)
+	p.checkEndpoint(nil, now.Add(2*time.Minute))
+	c.Assert(p.endpoints.active, Equals, 0)
+	c.Assert(p.client.DatapointEndpoint, Equals, primary.URL+"/v2/datapoint")
+}
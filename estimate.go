@@ -0,0 +1,55 @@
+package signalfx
+
+import (
+	"fmt"
+	"time"
+
+	metrics "github.com/rcrowley/go-metrics"
+)
+
+// UsageEstimate estimates the SignalFX usage implied by publishing a
+// registry, to predict its billing impact.
+type UsageEstimate struct {
+	// MTS is the number of active metric time series.
+	MTS int
+
+	// MinDPM is the number of datapoints per minute when no value changes,
+	// and only full flushes send datapoints.
+	MinDPM float64
+
+	// MaxDPM is the number of datapoints per minute when all values change
+	// between diff flushes.
+	MaxDPM float64
+}
+
+func (e UsageEstimate) String() string {
+	return fmt.Sprintf("usage estimate: mts=%d dpm=%.0f-%.0f", e.MTS, e.MinDPM, e.MaxDPM)
+}
+
+// EstimateUsage estimates the SignalFX usage implied by publishing the
+// registry with the options, given the metrics it currently holds. Nothing
+// is sent to SignalFX.
+func EstimateUsage(r metrics.Registry, opt Options) UsageEstimate {
+	setDefaults(&opt)
+	return newUsageEstimate(len(dryRun(r, opt).ds), opt)
+}
+
+// estimateUsage is EstimateUsage with the options of the publisher, which
+// reuses the dimensions it detected rather than running the detectors
+// again, and leaves out the lags rather than collecting them twice.
+func (p *publisher) estimateUsage(r metrics.Registry) UsageEstimate {
+	opt := p.opt
+	opt.ResourceDetectors = nil
+	opt.ServiceDimensions = false
+	opt.DefaultDimensions = p.defaultDims
+	opt.Lags = nil
+	return EstimateUsage(r, opt)
+}
+
+func newUsageEstimate(mts int, opt Options) UsageEstimate {
+	return UsageEstimate{
+		MTS:    mts,
+		MinDPM: float64(mts) * float64(time.Minute) / float64(opt.FullFrequency),
+		MaxDPM: float64(mts) * float64(time.Minute) / float64(opt.DiffFrequency),
+	}
+}
@@ -0,0 +1,43 @@
+package signalfx
+
+import (
+	"time"
+
+	metrics "github.com/rcrowley/go-metrics"
+	. "gopkg.in/check.v1"
+)
+
+func (s *Zuite) TestEstimateUsage(c *C) {
+	r := metrics.NewRegistry()
+	metrics.GetOrRegisterCounter("requests", r)
+	metrics.GetOrRegisterGauge("queue", r)
+	metrics.GetOrRegisterMeter("bytes", r)
+	other := metrics.NewRegistry()
+	metrics.GetOrRegisterCounter("errors", other)
+
+	estimate := EstimateUsage(r, Options{
+		DiffFrequency: 10 * time.Second,
+		Registries:    []metrics.Registry{other},
+		Filters: []Filter{FilterFunc(func(name string, _ interface{}) bool {
+			return name != "queue"
+		})},
+	})
+	c.Assert(estimate, Equals, UsageEstimate{MTS: 7, MinDPM: 7, MaxDPM: 42})
+	c.Assert(estimate.String(), Equals, "usage estimate: mts=7 dpm=7-42")
+}
+
+func (s *Zuite) TestEstimateUsage_publisher(c *C) {
+	r := metrics.NewRegistry()
+	metrics.GetOrRegisterCounter("requests", r)
+	detections := 0
+	p := newPublisher("", Options{ResourceDetectors: []ResourceDetector{
+		ResourceDetectorFunc(func() (map[string]string, error) {
+			detections++
+			return map[string]string{"host": "a"}, nil
+		}),
+	}})
+
+	// The publisher's estimate does not detect the resource again.
+	c.Assert(p.estimateUsage(r).MTS, Equals, 1)
+	c.Assert(detections, Equals, 1)
+}
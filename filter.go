@@ -0,0 +1,83 @@
+package signalfx
+
+import "regexp"
+
+// Filter decides which registry metrics are published, allowing central
+// governance policies such as naming standards to be enforced in code.
+type Filter interface {
+	// Keep reports whether the metric, registered under the name, should be
+	// published. The name is the registry name, which may carry dimensions
+	// encoded by NameWithDimensions.
+	Keep(name string, metric interface{}) bool
+}
+
+// FilterFunc adapts a function to a Filter.
+type FilterFunc func(name string, metric interface{}) bool
+
+// Keep calls f.
+func (f FilterFunc) Keep(name string, metric interface{}) bool {
+	return f(name, metric)
+}
+
+// keep reports whether all filters keep the metric.
+func keep(filters []Filter, name string, metric interface{}) bool {
+	for _, filter := range filters {
+		if !filter.Keep(name, metric) {
+			return false
+		}
+	}
+	return true
+}
+
+// NameFilter decides which datapoints are sent, by their final name,
+// including the submetrics of histograms, meters and timers such as
+// "latency.999-percentile", so that entire families can be filtered with
+// one rule.
+type NameFilter func(name string) bool
+
+// ExcludeMatching returns a NameFilter dropping the datapoints whose name
+// matches the regular expression, e.g. `\.(75|999)-percentile$`.
+func ExcludeMatching(re *regexp.Regexp) NameFilter {
+	return func(name string) bool {
+		return !re.MatchString(name)
+	}
+}
+
+// IncludeMatching returns a NameFilter dropping the datapoints whose name
+// does not match the regular expression.
+func IncludeMatching(re *regexp.Regexp) NameFilter {
+	return re.MatchString
+}
+
+// nameFilters caches the decisions of the NameFilters, by name.
+type nameFilters struct {
+	filters []NameFilter
+	kept    map[string]bool
+}
+
+func newNameFilters(filters []NameFilter) *nameFilters {
+	if len(filters) == 0 {
+		return nil
+	}
+	return &nameFilters{filters: filters, kept: make(map[string]bool)}
+}
+
+// keep reports whether all filters keep the datapoints of the name. A nil
+// *nameFilters keeps all datapoints.
+func (f *nameFilters) keep(name string) bool {
+	if f == nil {
+		return true
+	}
+	kept, ok := f.kept[name]
+	if !ok {
+		kept = true
+		for _, filter := range f.filters {
+			if !filter(name) {
+				kept = false
+				break
+			}
+		}
+		f.kept[name] = kept
+	}
+	return kept
+}
@@ -0,0 +1,47 @@
+package signalfx
+
+import (
+	"regexp"
+	"strings"
+
+	metrics "github.com/rcrowley/go-metrics"
+	. "gopkg.in/check.v1"
+)
+
+func (s *Zuite) TestMetricToDatapoints_filters(c *C) {
+	p := newPublisher("", Options{
+		Filters: []Filter{
+			FilterFunc(func(name string, _ interface{}) bool {
+				return !strings.HasPrefix(name, "debug.")
+			}),
+			FilterFunc(func(_ string, metric interface{}) bool {
+				_, isGauge := metric.(metrics.Gauge)
+				return !isGauge
+			}),
+		},
+	})
+
+	u := p.prepareUpdate()
+	u.metricToDatapoints("debug.requests", metrics.NewCounter())
+	u.metricToDatapoints("queue.size", metrics.NewGauge())
+	u.metricToDatapoints("requests", metrics.NewCounter())
+
+	c.Assert(u.ds, HasLen, 1)
+	c.Assert(u.ds[0].Metric, Equals, "requests")
+}
+
+func (s *Zuite) TestNameFilters(c *C) {
+	r := metrics.NewRegistry()
+	metrics.GetOrRegisterTimer("latency", r).Update(1)
+	metrics.GetOrRegisterCounter("requests", r).Inc(1)
+	metrics.GetOrRegisterCounter("debug.requests", r).Inc(1)
+
+	batch := Collect(r, Options{NameFilters: []NameFilter{
+		ExcludeMatching(regexp.MustCompile(`-(percentile|minute)$`)),
+		ExcludeMatching(regexp.MustCompile(`^debug\.`)),
+		IncludeMatching(regexp.MustCompile(`^(latency|requests)`)),
+	}})
+	c.Assert(batchNames(batch), DeepEquals, []string{
+		"latency.count", "latency.max", "latency.mean", "latency.mean-rate", "latency.min", "latency.std-dev", "requests",
+	})
+}
@@ -0,0 +1,46 @@
+package signalfx
+
+import metrics "github.com/rcrowley/go-metrics"
+
+// FunctionalCounter is a read-only metrics.Counter whose count is computed
+// when read, the counterpart of metrics.FunctionalGauge for values which only
+// increase, such as the totals collected from other libraries.
+type FunctionalCounter struct {
+	count func() int64
+}
+
+var _ metrics.Counter = FunctionalCounter{}
+
+// NewFunctionalCounter constructs a FunctionalCounter reading its count
+// from f.
+func NewFunctionalCounter(f func() int64) metrics.Counter {
+	if metrics.UseNilMetrics {
+		return metrics.NilCounter{}
+	}
+	return FunctionalCounter{count: f}
+}
+
+// Clear panics.
+func (FunctionalCounter) Clear() {
+	panic("Clear called on a FunctionalCounter")
+}
+
+// Count returns the current count.
+func (c FunctionalCounter) Count() int64 {
+	return c.count()
+}
+
+// Dec panics.
+func (FunctionalCounter) Dec(int64) {
+	panic("Dec called on a FunctionalCounter")
+}
+
+// Inc panics.
+func (FunctionalCounter) Inc(int64) {
+	panic("Inc called on a FunctionalCounter")
+}
+
+// Snapshot returns a read-only copy of the counter.
+func (c FunctionalCounter) Snapshot() metrics.Counter {
+	return metrics.CounterSnapshot(c.Count())
+}
@@ -0,0 +1,17 @@
+package signalfx
+
+import (
+	. "gopkg.in/check.v1"
+)
+
+func (s *Zuite) TestFunctionalCounter(c *C) {
+	var n int64
+	counter := NewFunctionalCounter(func() int64 { return n })
+	n = 3
+	c.Assert(counter.Count(), Equals, int64(3))
+	snapshot := counter.Snapshot()
+	n = 4
+	c.Assert(snapshot.Count(), Equals, int64(3))
+	c.Assert(counter.Count(), Equals, int64(4))
+	c.Assert(func() { counter.Inc(1) }, PanicMatches, "Inc called on a FunctionalCounter")
+}
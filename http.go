@@ -0,0 +1,114 @@
+package signalfx
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	metrics "github.com/rcrowley/go-metrics"
+)
+
+// HTTP server metrics, with the method and route of requests as dimensions.
+// Responses also have the status code as a dimension.
+const (
+	HTTPRequestsMetric  = "http.server.requests"
+	HTTPResponsesMetric = "http.server.responses"
+)
+
+// RecordHTTPRequest records in r a request served in d, which matched the
+// route and was responded to with status. The route should be the template
+// of the path, e.g. /users/{id}, rather than the path itself to keep the
+// cardinality low. It is used by the middlewares of this package, and of the
+// signalfxchi, signalfxecho and signalfxgin packages.
+func RecordHTTPRequest(r metrics.Registry, method, route string, status int, d time.Duration) {
+	dims := map[string]string{
+		"method": method,
+		"route":  route,
+	}
+	metrics.GetOrRegisterTimer(NameWithDimensions(HTTPRequestsMetric, dims), r).Update(d)
+	dims["status"] = strconv.Itoa(status)
+	metrics.GetOrRegisterCounter(NameWithDimensions(HTTPResponsesMetric, dims), r).Inc(1)
+}
+
+// HTTPMiddleware returns net/http middleware recording the requests served
+// in r. The route of requests is the pattern they matched in an
+// http.ServeMux, or "unmatched":
+//
+// 	http.ListenAndServe(":8080", signalfx.HTTPMiddleware(metrics.DefaultRegistry)(mux))
+func HTTPMiddleware(r metrics.Registry) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			start := time.Now()
+			recorder := &StatusRecorder{ResponseWriter: w}
+			next.ServeHTTP(recorder, req)
+			route := req.Pattern
+			if route == "" {
+				route = "unmatched"
+			}
+			RecordHTTPRequest(r, req.Method, route, recorder.Status(), time.Since(start))
+		})
+	}
+}
+
+// StatusRecorder is an http.ResponseWriter recording the status code of the
+// response. It is an http.Flusher and an http.Hijacker when the wrapped
+// writer is, for server-sent events and websockets.
+type StatusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+// WriteHeader records the status code, and writes it.
+func (w *StatusRecorder) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *StatusRecorder) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Status returns the status code of the response, 200 if none was written.
+func (w *StatusRecorder) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+// Flush flushes the wrapped writer, if it is an http.Flusher.
+func (w *StatusRecorder) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		if w.status == 0 {
+			w.status = http.StatusOK
+		}
+		f.Flush()
+	}
+}
+
+// Hijack hijacks the connection of the wrapped writer, if it is an
+// http.Hijacker, recording the status 101 unless one was written.
+func (w *StatusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("signalfx: the response writer is not an http.Hijacker")
+	}
+	conn, rw, err := h.Hijack()
+	if err == nil && w.status == 0 {
+		w.status = http.StatusSwitchingProtocols
+	}
+	return conn, rw, err
+}
+
+// Unwrap returns the wrapped http.ResponseWriter, for http.ResponseController.
+func (w *StatusRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,68 @@
+package signalfx
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	metrics "github.com/rcrowley/go-metrics"
+	. "gopkg.in/check.v1"
+)
+
+func (s *Zuite) TestHTTPMiddleware(c *C) {
+	r := metrics.NewRegistry()
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	handler := HTTPMiddleware(r)(mux)
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/1", nil))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/2", nil))
+
+	timer := r.Get(NameWithDimensions(HTTPRequestsMetric, map[string]string{
+		"method": "GET",
+		"route":  "GET /users/{id}",
+	}))
+	c.Assert(timer, NotNil)
+	c.Assert(timer.(metrics.Timer).Count(), Equals, int64(2))
+	counter := r.Get(NameWithDimensions(HTTPResponsesMetric, map[string]string{
+		"method": "GET",
+		"route":  "GET /users/{id}",
+		"status": "404",
+	}))
+	c.Assert(counter, NotNil)
+	c.Assert(counter.(metrics.Counter).Count(), Equals, int64(2))
+}
+
+func (s *Zuite) TestStatusRecorder(c *C) {
+	recorder := &StatusRecorder{ResponseWriter: httptest.NewRecorder()}
+	c.Assert(recorder.Status(), Equals, 200)
+	recorder.Write([]byte("body"))
+	recorder.WriteHeader(500)
+	c.Assert(recorder.Status(), Equals, 200)
+}
+
+func (s *Zuite) TestStatusRecorder_flushHijack(c *C) {
+	w := httptest.NewRecorder()
+	var recorder http.ResponseWriter = &StatusRecorder{ResponseWriter: w}
+	recorder.(http.Flusher).Flush()
+	c.Assert(w.Flushed, Equals, true)
+
+	// The connections of servers can be hijacked through the recorder.
+	hijacked := make(chan int, 1)
+	server := httptest.NewServer(HTTPMiddleware(metrics.NewRegistry())(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		conn, _, err := w.(http.Hijacker).Hijack()
+		c.Check(err, IsNil)
+		conn.Write([]byte("HTTP/1.1 101 Switching Protocols\r\n\r\n"))
+		conn.Close()
+		hijacked <- w.(*StatusRecorder).Status()
+	})))
+	defer server.Close()
+	resp, err := http.Get(server.URL)
+	c.Assert(err, IsNil)
+	resp.Body.Close()
+	c.Assert(<-hijacked, Equals, http.StatusSwitchingProtocols)
+
+	_, _, err = recorder.(http.Hijacker).Hijack()
+	c.Assert(err, NotNil)
+}
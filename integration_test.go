@@ -0,0 +1,37 @@
+package signalfx
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	metrics "github.com/rcrowley/go-metrics"
+	. "gopkg.in/check.v1"
+)
+
+// TestIntegration publishes to SignalFX and verifies delivery through the
+// API. It only runs when SIGNALFX_INTEGRATION_TOKEN, an ingest token, and
+// SIGNALFX_INTEGRATION_API_TOKEN are set. SIGNALFX_INTEGRATION_REALM sets
+// the realm, for realms other than us0.
+func (s *Zuite) TestIntegration(c *C) {
+	token, apiToken := os.Getenv("SIGNALFX_INTEGRATION_TOKEN"), os.Getenv("SIGNALFX_INTEGRATION_API_TOKEN")
+	if token == "" || apiToken == "" {
+		c.Skip("SIGNALFX_INTEGRATION_TOKEN and SIGNALFX_INTEGRATION_API_TOKEN are not set")
+	}
+	realm := os.Getenv("SIGNALFX_INTEGRATION_REALM")
+
+	start := time.Now()
+	name := NameWithDimensions("signalfx.integration", map[string]string{"run": fmt.Sprint(start.UnixNano())})
+	r := metrics.NewRegistry()
+	metrics.GetOrRegisterCounter(name, r).Inc(1)
+	metrics.GetOrRegisterGaugeFloat64(name+".gauge", r).Update(1.5)
+
+	p := newPublisher(token, Options{Realm: realm})
+	c.Assert(p.single(r), IsNil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Minute)
+	defer cancel()
+	v := &Verifier{Token: apiToken, Realm: realm}
+	c.Assert(v.VerifyDelivery(ctx, name, start.Add(-time.Minute)), IsNil)
+}
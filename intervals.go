@@ -0,0 +1,39 @@
+package signalfx
+
+import (
+	"github.com/signalfx/golib/sfxclient"
+)
+
+// appendIfEventsChanged appends the count of a meter or timer, as the number
+// of events since it was last sent if IntervalCounts is set.
+func (u *update) appendIfEventsChanged(name string, count int64) {
+	if u.p.intervals == nil {
+		u.appendIfCountChanged(name, count)
+		return
+	}
+	s, ok := u.series(name)
+	if !ok {
+		return
+	}
+	if last, ok := u.p.last.counters[s.key]; ok && !u.changed(s, float64(last), float64(count)) {
+		u.stats.Suppressed++
+		return
+	}
+	events := count - u.p.intervals[s.key]
+	if events < 0 {
+		// The metric was reset, or replaced.
+		events = count
+	}
+	if u.append(s.key, sfxclient.Counter(s.name, s.dims, events)) {
+		u.changes.counters[s.key] = count
+		u.changes.intervals[s.key] = count
+	}
+}
+
+// retained records that the datapoint of the series is kept to be resent,
+// so that its events are not counted again by the next interval.
+func (u *update) retained(key string) {
+	if count, ok := u.changes.intervals[key]; ok {
+		u.p.intervals[key] = count
+	}
+}
@@ -0,0 +1,75 @@
+package signalfx
+
+import (
+	"errors"
+
+	metrics "github.com/rcrowley/go-metrics"
+	. "gopkg.in/check.v1"
+)
+
+func (s *Zuite) TestIntervalCounts(c *C) {
+	p, sender := newTestPublisher(Options{IntervalCounts: true})
+
+	r := metrics.NewRegistry()
+	meter := metrics.GetOrRegisterMeter("requests", r)
+	timer := metrics.GetOrRegisterTimer("latency", r)
+	meter.Mark(3)
+	timer.Update(1)
+	c.Assert(p.single(r), IsNil)
+	c.Assert(sentValue(c, sender, "requests.count"), Equals, 3.0)
+	c.Assert(sentValue(c, sender, "latency.count"), Equals, 1.0)
+
+	// Unchanged counts are suppressed, and a full flush sends no events.
+	sender.sent = nil
+	meter.Mark(2)
+	c.Assert(p.single(r), IsNil)
+	c.Assert(sentValue(c, sender, "requests.count"), Equals, 2.0)
+	_, ok := newBatch(sender.sent).Get("latency.count", nil)
+	c.Assert(ok, Equals, false)
+	p.resetCaches()
+	sender.sent = nil
+	c.Assert(p.single(r), IsNil)
+	c.Assert(sentValue(c, sender, "requests.count"), Equals, 0.0)
+
+	// Events of failed flushes are counted by the next interval.
+	sender.err = errors.New("unreachable")
+	meter.Mark(4)
+	c.Assert(p.single(r), ErrorMatches, "unreachable")
+	sender.err = nil
+	meter.Mark(1)
+	c.Assert(p.single(r), IsNil)
+	c.Assert(sentValue(c, sender, "requests.count"), Equals, 5.0)
+}
+
+func (s *Zuite) TestIntervalCounts_buffered(c *C) {
+	p, sender := newTestPublisher(Options{IntervalCounts: true, BufferSize: 10})
+	sender.err = errors.New("unreachable")
+
+	r := metrics.NewRegistry()
+	meter := metrics.GetOrRegisterMeter("requests", r)
+	meter.Mark(4)
+	c.Assert(p.single(r), ErrorMatches, "unreachable")
+	sender.err = nil
+	meter.Mark(1)
+	c.Assert(p.single(r), IsNil)
+
+	var counts []float64
+	for _, point := range newBatch(sender.sent) {
+		if point.Name == "requests.count" {
+			counts = append(counts, point.Value)
+		}
+	}
+	c.Assert(counts, DeepEquals, []float64{4, 1})
+}
+
+// sentValue returns the value last sent for the series without dimensions.
+func sentValue(c *C, sender *fakeSender, name string) float64 {
+	batch := newBatch(sender.sent)
+	for i := len(batch) - 1; i >= 0; i-- {
+		if batch[i].Name == name {
+			return batch[i].Value
+		}
+	}
+	c.Fatalf("%s not sent", name)
+	return 0
+}
@@ -0,0 +1,61 @@
+package signalfx
+
+import (
+	metrics "github.com/rcrowley/go-metrics"
+)
+
+// LagCollector reports the lag or depth of queues, such as the consumer lag
+// of the partitions of a Kafka topic, or the depth of SQS queues.
+type LagCollector interface {
+	// Collect returns the lag or depth of each queue, by the name of the
+	// queue.
+	Collect() (map[string]int64, error)
+}
+
+// LagCollectorFunc is a function implementing LagCollector.
+type LagCollectorFunc func() (map[string]int64, error)
+
+// Collect calls f.
+func (f LagCollectorFunc) Collect() (map[string]int64, error) {
+	return f()
+}
+
+// Lag describes how the lags reported by a collector are published: as
+// gauges named Metric, with the name of the queue as the dimension
+// Dimension, and the Dimensions. For instance,
+//
+// 	signalfx.Lag{
+// 		Metric:     "kafka.consumer.lag",
+// 		Dimension:  "partition",
+// 		Dimensions: map[string]string{"topic": "orders", "group": "billing"},
+// 		Collector:  signalfx.LagCollectorFunc(consumerLag),
+// 	}
+//
+// publishes the gauges kafka.consumer.lag with dimensions partition, topic
+// and group.
+type Lag struct {
+	Metric     string
+	Dimension  string
+	Dimensions map[string]string
+	Collector  LagCollector
+}
+
+// collectLags collects the lags on each flush, in a registry of gauges
+// published along the others. Collectors failing are logged, and skipped.
+func (p *publisher) collectLags() metrics.Registry {
+	r := metrics.NewRegistry()
+	for _, lag := range p.opt.Lags {
+		lags, err := lag.Collector.Collect()
+		if err != nil {
+			p.logf(levelWarning, "WARNING: unable to collect %s: %s", lag.Metric, err)
+			continue
+		}
+		for queue, value := range lags {
+			dims := mergeDimensions(lag.Dimensions, map[string]string{lag.Dimension: queue})
+			gauge := metrics.NewGauge()
+			gauge.Update(value)
+			r.Register(NameWithDimensions(lag.Metric, dims), gauge)
+		}
+	}
+	return r
+}
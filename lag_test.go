@@ -0,0 +1,40 @@
+package signalfx
+
+import (
+	"errors"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *Zuite) TestCollectLags(c *C) {
+	logger := &recordingLogger{}
+	p := newPublisher("", Options{
+		Logger: logger,
+		Lags: []Lag{{
+			Metric:     "kafka.consumer.lag",
+			Dimension:  "partition",
+			Dimensions: map[string]string{"topic": "orders"},
+			Collector: LagCollectorFunc(func() (map[string]int64, error) {
+				return map[string]int64{"0": 12, "1": 0}, nil
+			}),
+		}, {
+			Metric:    "sqs.depth",
+			Dimension: "queue",
+			Collector: LagCollectorFunc(func() (map[string]int64, error) {
+				return nil, errors.New("access denied")
+			}),
+		}},
+	})
+
+	u := p.prepareUpdate()
+	p.collectLags().Each(u.metricToDatapoints)
+
+	values := make(map[string]string)
+	for _, d := range u.ds {
+		c.Assert(d.Metric, Equals, "kafka.consumer.lag")
+		c.Assert(d.Dimensions["topic"], Equals, "orders")
+		values[d.Dimensions["partition"]] = d.Value.String()
+	}
+	c.Assert(values, DeepEquals, map[string]string{"0": "12", "1": "0"})
+	c.Assert(logger.lines, DeepEquals, []string{"WARNING: unable to collect sqs.depth: access denied"})
+}
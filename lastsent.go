@@ -0,0 +1,94 @@
+package signalfx
+
+import (
+	"sync"
+	"time"
+)
+
+// lastSent keeps the points last sent for each series, for introspection.
+type lastSent struct {
+	mu     sync.Mutex
+	points map[string]Point
+}
+
+func newLastSent() *lastSent {
+	return &lastSent{points: make(map[string]Point)}
+}
+
+// record keeps the datapoints of the update which were sent, timing those
+// without timestamp with the time of the update.
+func (l *lastSent) record(u *update, sent func(key string) bool) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, d := range u.ds {
+		key := u.keys[d]
+		if !sent(key) {
+			continue
+		}
+		point := newPoint(d)
+		if point.Time.IsZero() {
+			point.Time = u.now
+		}
+		l.points[key] = point
+	}
+}
+
+// value returns the value last sent for the series, and when.
+func (l *lastSent) value(key string) (float64, time.Time, bool) {
+	if l == nil {
+		return 0, time.Time{}, false
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	point, ok := l.points[key]
+	return point.Value, point.Time, ok
+}
+
+func (l *lastSent) snapshot() map[string]Point {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	snapshot := make(map[string]Point, len(l.points))
+	for key, point := range l.points {
+		snapshot[key] = point
+	}
+	return snapshot
+}
+
+// LastSent returns the value last sent for the series, and when it was
+// sent. The series is identified by its name and dimensions, as encoded by
+// NameWithDimensions, the default dimensions excluded, e.g.
+// "latency.mean[route=/users]".
+func (a *Admin) LastSent(series string) (float64, time.Time, bool) {
+	if p := a.publisher(); p != nil {
+		return p.lastSent.value(series)
+	}
+	return 0, time.Time{}, false
+}
+
+// Snapshot returns the points last sent for all series, keyed as in
+// LastSent. The Time of points is when they were sent, unless they are
+// timestamped.
+func (a *Admin) Snapshot() map[string]Point {
+	if p := a.publisher(); p != nil {
+		return p.lastSent.snapshot()
+	}
+	return nil
+}
+
+// LastSent returns the value last sent for the series, and when it was
+// sent, as Admin.LastSent does.
+func (pub *Publisher) LastSent(series string) (float64, time.Time, bool) {
+	return pub.p.lastSent.value(series)
+}
+
+// Snapshot returns the points last sent for all series, as Admin.Snapshot
+// does.
+func (pub *Publisher) Snapshot() map[string]Point {
+	return pub.p.lastSent.snapshot()
+}
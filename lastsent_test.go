@@ -0,0 +1,56 @@
+package signalfx
+
+import (
+	"errors"
+
+	metrics "github.com/rcrowley/go-metrics"
+	. "gopkg.in/check.v1"
+)
+
+func (s *Zuite) TestAdmin_LastSent(c *C) {
+	admin := NewAdmin()
+	p, sender := newTestPublisher(Options{Admin: admin})
+
+	_, _, ok := admin.LastSent("requests")
+	c.Assert(ok, Equals, false)
+
+	r := metrics.NewRegistry()
+	counter := metrics.GetOrRegisterCounter("requests[route=/users]", r)
+	counter.Inc(3)
+	c.Assert(p.single(r), IsNil)
+
+	value, sent, ok := admin.LastSent("requests[route=/users]")
+	c.Assert(ok, Equals, true)
+	c.Assert(value, Equals, float64(3))
+	c.Assert(sent.IsZero(), Equals, false)
+
+	// Values which failed to be sent are not recorded.
+	counter.Inc(1)
+	sender.err = errors.New("unreachable")
+	c.Assert(p.single(r), NotNil)
+	value, _, _ = admin.LastSent("requests[route=/users]")
+	c.Assert(value, Equals, float64(3))
+
+	snapshot := admin.Snapshot()
+	c.Assert(snapshot, HasLen, 1)
+	point := snapshot["requests[route=/users]"]
+	c.Assert(point.Name, Equals, "requests")
+	c.Assert(point.Type, Equals, PointCounter)
+	c.Assert(point.Dims, DeepEquals, map[string]string{"route": "/users"})
+	c.Assert(point.Time, Equals, sent)
+}
+
+func (s *Zuite) TestPublisher_LastSent(c *C) {
+	sender := &fakeSender{}
+	r := metrics.NewRegistry()
+	publisher := New(r, "")
+	publisher.p.sender = sender
+
+	metrics.GetOrRegisterGauge("queue", r).Update(7)
+	c.Assert(publisher.p.single(r), IsNil)
+
+	value, _, ok := publisher.LastSent("queue")
+	c.Assert(ok, Equals, true)
+	c.Assert(value, Equals, float64(7))
+	c.Assert(publisher.Snapshot(), HasLen, 1)
+}
@@ -0,0 +1,174 @@
+package signalfx
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+
+	metrics "github.com/rcrowley/go-metrics"
+	"github.com/signalfx/golib/datapoint"
+	"github.com/signalfx/golib/sfxclient"
+)
+
+// logLevel orders the publisher's logs by verbosity.
+type logLevel int
+
+const (
+	// levelWarning is for errors and warnings, which are always logged.
+	levelWarning logLevel = iota
+
+	// levelInfo is for per-flush summaries.
+	levelInfo
+
+	// levelVerbose is for debugging output.
+	levelVerbose
+
+	// levelTrace is for per-datapoint debugging output.
+	levelTrace
+)
+
+// logger returns the logger of the level: the ErrorLogger for errors and
+// warnings, if set, or else the Logger.
+func (p *publisher) logger(level logLevel) metrics.Logger {
+	if level == levelWarning && p.opt.ErrorLogger != nil {
+		return p.opt.ErrorLogger
+	}
+	return p.opt.Logger
+}
+
+// enabled reports whether logs of the level are logged.
+func (p *publisher) enabled(level logLevel) bool {
+	if p.logger(level) == nil {
+		return false
+	}
+	switch level {
+	case levelInfo:
+		return p.opt.Summary
+	case levelVerbose:
+		return p.opt.Verbose || p.opt.Trace
+	case levelTrace:
+		return p.opt.Trace
+	}
+	return true
+}
+
+var logLevelNames = map[logLevel]string{
+	levelWarning: "warning",
+	levelInfo:    "info",
+	levelVerbose: "verbose",
+	levelTrace:   "trace",
+}
+
+// logf logs through the Logger, if the level is enabled.
+func (p *publisher) logf(level logLevel, format string, v ...interface{}) {
+	if !p.enabled(level) {
+		return
+	}
+	if p.opt.JSONLogs {
+		p.logJSON(level, fmt.Sprintf(format, v...), nil)
+		return
+	}
+	p.logger(level).Printf(format, v...)
+}
+
+// logJSON logs a JSON object made of the level, the message, and the fields.
+func (p *publisher) logJSON(level logLevel, msg string, fields map[string]interface{}) {
+	object := map[string]interface{}{
+		"level": logLevelNames[level],
+		"msg":   msg,
+	}
+	for key, value := range fields {
+		object[key] = value
+	}
+	line, err := json.Marshal(object)
+	if err != nil {
+		p.logger(level).Printf("%s (unable to encode log as JSON: %s)", msg, err)
+		return
+	}
+	p.logger(level).Printf("%s", line)
+}
+
+// logFlush logs the summary of a flush, at the info level.
+func (p *publisher) logFlush(stats FlushStats) {
+	if !p.enabled(levelInfo) {
+		return
+	}
+	if !p.opt.JSONLogs {
+		p.logger(levelInfo).Printf("%s", stats)
+		return
+	}
+	fields := map[string]interface{}{
+		"emitted":     stats.Emitted,
+		"suppressed":  stats.Suppressed,
+		"late":        stats.Late,
+		"rejected":    stats.Rejected,
+		"batches":     stats.Batches,
+		"bytes":       stats.Bytes,
+		"total_bytes": stats.TotalBytes,
+		"latency_ms":  stats.Latency.Seconds() * 1000,
+		"outcome":     "ok",
+	}
+	if stats.Err != nil {
+		fields["outcome"] = "error"
+		fields["error"] = stats.Err.Error()
+		fields["error_class"] = errorClass(stats.Err)
+	}
+	p.logJSON(levelInfo, "flush summary", fields)
+}
+
+// logPublishError logs a failure to publish.
+func (p *publisher) logPublishError(err error) {
+	if !p.enabled(levelWarning) {
+		return
+	}
+	if !p.opt.JSONLogs {
+		p.logger(levelWarning).Printf("Unable to publish to SignalFX: %s.", err)
+		return
+	}
+	p.logJSON(levelWarning, "unable to publish to SignalFX", map[string]interface{}{
+		"error":       err.Error(),
+		"error_class": errorClass(err),
+	})
+}
+
+// errorClass classifies errors for alerting: "api" for errors returned by
+// the SignalFX API, "timeout", "network", "canceled", or "other".
+func errorClass(err error) string {
+	err = cause(err)
+	if _, ok := err.(sfxclient.SFXAPIError); ok {
+		return "api"
+	}
+	if err == context.Canceled || err == context.DeadlineExceeded {
+		return "canceled"
+	}
+	if netErr, ok := err.(net.Error); ok {
+		if netErr.Timeout() {
+			return "timeout"
+		}
+		return "network"
+	}
+	return "other"
+}
+
+// traceDatapoints logs every datapoint, at the trace level.
+func (p *publisher) traceDatapoints(ds []*datapoint.Datapoint) {
+	if !p.enabled(levelTrace) {
+		return
+	}
+	for _, d := range ds {
+		p.logf(levelTrace, "datapoint %s %s=%s dimensions=%v", metricTypeName(d.MetricType), d.Metric, d.Value, d.Dimensions)
+	}
+}
+
+func metricTypeName(mt datapoint.MetricType) string {
+	switch mt {
+	case datapoint.Gauge:
+		return "gauge"
+	case datapoint.Count:
+		return "counter"
+	case datapoint.Counter:
+		return "cumulative_counter"
+	}
+	return fmt.Sprintf("type_%d", mt)
+}
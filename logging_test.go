@@ -0,0 +1,125 @@
+package signalfx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/signalfx/golib/datapoint"
+	"github.com/signalfx/golib/sfxclient"
+	. "gopkg.in/check.v1"
+)
+
+// recordingLogger records the lines it is asked to print.
+type recordingLogger struct {
+	lines []string
+}
+
+func (l *recordingLogger) Printf(format string, v ...interface{}) {
+	l.lines = append(l.lines, fmt.Sprintf(format, v...))
+}
+
+func (s *Zuite) TestLogf_levels(c *C) {
+	for _, example := range []struct {
+		opt      Options
+		expected []string
+	}{
+		{Options{}, []string{"warning"}},
+		{Options{Summary: true}, []string{"warning", "info"}},
+		{Options{Verbose: true}, []string{"warning", "verbose"}},
+		{Options{Trace: true}, []string{"warning", "verbose", "trace"}},
+	} {
+		logger := &recordingLogger{}
+		example.opt.Logger = logger
+		p := newPublisher("", example.opt)
+
+		p.logf(levelWarning, "warning")
+		p.logf(levelInfo, "info")
+		p.logf(levelVerbose, "verbose")
+		p.logf(levelTrace, "trace")
+
+		c.Assert(logger.lines, DeepEquals, example.expected)
+	}
+}
+
+func (s *Zuite) TestLogf_errorLogger(c *C) {
+	logger, errLogger := &recordingLogger{}, &recordingLogger{}
+	p := newPublisher("", Options{Logger: logger, ErrorLogger: errLogger, Verbose: true})
+
+	p.logf(levelWarning, "warning")
+	p.logf(levelVerbose, "verbose")
+	p.logPublishError(fmt.Errorf("unreachable"))
+
+	c.Assert(logger.lines, DeepEquals, []string{"verbose"})
+	c.Assert(errLogger.lines, DeepEquals, []string{"warning", "Unable to publish to SignalFX: unreachable."})
+
+	// Errors are logged without any other logger.
+	p = newPublisher("", Options{ErrorLogger: errLogger, Verbose: true})
+	errLogger.lines = nil
+	p.logf(levelWarning, "warning")
+	p.logf(levelVerbose, "verbose")
+	c.Assert(errLogger.lines, DeepEquals, []string{"warning"})
+}
+
+func (s *Zuite) TestTraceDatapoints(c *C) {
+	logger := &recordingLogger{}
+	p := newPublisher("", Options{Logger: logger, Trace: true})
+
+	p.traceDatapoints([]*datapoint.Datapoint{
+		sfxclient.Counter("requests", map[string]string{"route": "/"}, 5),
+		sfxclient.GaugeF("load", nil, 0.5),
+	})
+
+	c.Assert(logger.lines, DeepEquals, []string{
+		"datapoint counter requests=5 dimensions=map[route:/]",
+		"datapoint gauge load=0.5 dimensions=map[]",
+	})
+}
+
+func (s *Zuite) TestLogf_json(c *C) {
+	logger := &recordingLogger{}
+	p := newPublisher("", Options{Logger: logger, JSONLogs: true, Summary: true})
+
+	p.logf(levelWarning, "series cap of %d reached", 10)
+	p.logFlush(FlushStats{Emitted: 3, Suppressed: 2, Batches: 1, Bytes: 40, TotalBytes: 80, Latency: 1500 * time.Microsecond})
+	p.logPublishError(sfxclient.SFXAPIError{StatusCode: 401, ResponseBody: "unauthorized"})
+
+	c.Assert(logger.lines, DeepEquals, []string{
+		`{"level":"warning","msg":"series cap of 10 reached"}`,
+		`{"batches":1,"bytes":40,"emitted":3,"late":0,"latency_ms":1.5,"level":"info","msg":"flush summary","outcome":"ok","rejected":0,"suppressed":2,"total_bytes":80}`,
+		`{"error":"invalid status code 401: unauthorized","error_class":"api","level":"warning","msg":"unable to publish to SignalFX"}`,
+	})
+}
+
+func (s *Zuite) TestErrorClass(c *C) {
+	c.Assert(errorClass(sfxclient.SFXAPIError{StatusCode: 400}), Equals, "api")
+	c.Assert(errorClass(context.DeadlineExceeded), Equals, "canceled")
+	c.Assert(errorClass(&net.DNSError{IsTimeout: true}), Equals, "timeout")
+	c.Assert(errorClass(&net.DNSError{}), Equals, "network")
+	c.Assert(errorClass(errors.New("boom")), Equals, "other")
+}
+
+func (s *Zuite) TestErrorClass_sink(c *C) {
+	unavailable := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer unavailable.Close()
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+	points := []*datapoint.Datapoint{sfxclient.Gauge("requests", nil, 1)}
+	sink := sfxclient.NewHTTPSink()
+
+	sink.DatapointEndpoint = unavailable.URL
+	c.Assert(errorClass(sink.AddDatapoints(context.Background(), points)), Equals, "api")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	c.Assert(errorClass(sink.AddDatapoints(ctx, points)), Equals, "canceled")
+
+	sink.DatapointEndpoint = closed.URL
+	c.Assert(errorClass(sink.AddDatapoints(context.Background(), points)), Equals, "network")
+}
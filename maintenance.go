@@ -0,0 +1,177 @@
+package signalfx
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaintenanceWindow is a recurring window of time during which publishing is
+// paused, or reduced to full flushes, e.g. for planned network maintenance or
+// to reduce costs overnight in non-production environments.
+type MaintenanceWindow struct {
+	// Schedule is the start of the window, as a cron spec of five fields:
+	// minute, hour, day of month, month, and day of week (0 is Sunday).
+	// Fields are *, values, ranges, lists, and steps, e.g. "0 22 * * 1-5"
+	// for 10pm on weekdays.
+	Schedule string
+
+	// Duration is the length of the window.
+	Duration time.Duration
+
+	// FullOnly keeps publishing full flushes during the window, only diff
+	// flushes are skipped.
+	FullOnly bool
+
+	// Location is the time zone of the schedule, defaulting to local time.
+	Location *time.Location
+}
+
+// maintenance is the state of publishing with regards to maintenance windows.
+type maintenance int
+
+const (
+	maintenanceNone maintenance = iota
+	maintenanceFullOnly
+	maintenancePaused
+)
+
+// window is a parsed MaintenanceWindow.
+type window struct {
+	MaintenanceWindow
+	schedule *cronSchedule
+}
+
+// parseWindows parses the maintenance windows, logging and ignoring the
+// invalid ones.
+func parseWindows(windows []MaintenanceWindow, logf func(string, ...interface{})) []window {
+	var parsed []window
+	for _, w := range windows {
+		schedule, err := parseCron(w.Schedule)
+		if err != nil {
+			logf("WARNING: ignoring maintenance window %q: %s", w.Schedule, err)
+			continue
+		}
+		if w.Location == nil {
+			w.Location = time.Local
+		}
+		parsed = append(parsed, window{MaintenanceWindow: w, schedule: schedule})
+	}
+	return parsed
+}
+
+// contains reports whether the window contains t, i.e. whether it started
+// within its duration before t.
+func (w window) contains(t time.Time) bool {
+	t = t.In(w.Location).Truncate(time.Minute)
+	for d := time.Duration(0); d < w.Duration; d += time.Minute {
+		if w.schedule.matches(t.Add(-d)) {
+			return true
+		}
+	}
+	return false
+}
+
+// maintenance returns the state of publishing at t: paused if a window
+// pausing publishing contains t, or reduced to full flushes if another one
+// does. Entering and leaving maintenance is logged.
+func (p *publisher) maintenance(t time.Time) maintenance {
+	state := maintenanceNone
+	for _, w := range p.windows {
+		if !w.contains(t) {
+			continue
+		}
+		if !w.FullOnly {
+			state = maintenancePaused
+			break
+		}
+		state = maintenanceFullOnly
+	}
+	if state != p.inMaintenance {
+		switch state {
+		case maintenanceNone:
+			p.logf(levelInfo, "maintenance window ended, resuming publishing")
+		case maintenanceFullOnly:
+			p.logf(levelInfo, "maintenance window started, only publishing full flushes")
+		case maintenancePaused:
+			p.logf(levelInfo, "maintenance window started, pausing publishing")
+		}
+		p.inMaintenance = state
+	}
+	return state
+}
+
+// cronSchedule is a parsed cron spec, with the allowed values of each field.
+type cronSchedule struct {
+	minutes, hours, days, months, weekdays map[int]bool
+}
+
+// matches reports whether the minute t is in the schedule.
+func (s *cronSchedule) matches(t time.Time) bool {
+	return s.minutes[t.Minute()] && s.hours[t.Hour()] && s.days[t.Day()] &&
+		s.months[int(t.Month())] && s.weekdays[int(t.Weekday())]
+}
+
+// parseCron parses a cron spec of five fields.
+func parseCron(spec string) (*cronSchedule, error) {
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("expected 5 fields, got %d", len(fields))
+	}
+	var s cronSchedule
+	for i, f := range []struct {
+		values   *map[int]bool
+		min, max int
+	}{
+		{&s.minutes, 0, 59},
+		{&s.hours, 0, 23},
+		{&s.days, 1, 31},
+		{&s.months, 1, 12},
+		{&s.weekdays, 0, 6},
+	} {
+		values, err := parseCronField(fields[i], f.min, f.max)
+		if err != nil {
+			return nil, fmt.Errorf("field %q: %s", fields[i], err)
+		}
+		*f.values = values
+	}
+	return &s, nil
+}
+
+// parseCronField parses a field of a cron spec: a list of *, values, or
+// ranges, each optionally with a step.
+func parseCronField(field string, min, max int) (map[int]bool, error) {
+	values := make(map[int]bool)
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if i := strings.IndexByte(part, '/'); i >= 0 {
+			var err error
+			if step, err = strconv.Atoi(part[i+1:]); err != nil || step <= 0 {
+				return nil, fmt.Errorf("invalid step %q", part[i+1:])
+			}
+			part = part[:i]
+		}
+		from, to := min, max
+		if part != "*" {
+			bounds := strings.SplitN(part, "-", 2)
+			var err error
+			if from, err = strconv.Atoi(bounds[0]); err != nil {
+				return nil, fmt.Errorf("invalid value %q", bounds[0])
+			}
+			to = from
+			if len(bounds) == 2 {
+				if to, err = strconv.Atoi(bounds[1]); err != nil {
+					return nil, fmt.Errorf("invalid value %q", bounds[1])
+				}
+			}
+		}
+		if from < min || to > max || from > to {
+			return nil, fmt.Errorf("%q out of range %d-%d", part, min, max)
+		}
+		for v := from; v <= to; v += step {
+			values[v] = true
+		}
+	}
+	return values, nil
+}
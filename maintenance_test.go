@@ -0,0 +1,61 @@
+package signalfx
+
+import (
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *Zuite) TestParseCron(c *C) {
+	schedule, err := parseCron("*/15 22 * * 1-5")
+	c.Assert(err, IsNil)
+	c.Assert(schedule.minutes, DeepEquals, map[int]bool{0: true, 15: true, 30: true, 45: true})
+	c.Assert(schedule.hours, DeepEquals, map[int]bool{22: true})
+	c.Assert(schedule.days, HasLen, 31)
+	c.Assert(schedule.weekdays, DeepEquals, map[int]bool{1: true, 2: true, 3: true, 4: true, 5: true})
+
+	schedule, err = parseCron("0 1,3 * * *")
+	c.Assert(err, IsNil)
+	c.Assert(schedule.hours, DeepEquals, map[int]bool{1: true, 3: true})
+
+	for spec, msg := range map[string]string{
+		"* * * *":     "expected 5 fields, got 4",
+		"60 * * * *":  `field "60": "60" out of range 0-59`,
+		"* x * * *":   `field "x": invalid value "x"`,
+		"*/0 * * * *": `field "\*/0": invalid step "0"`,
+		"* * 5-2 * *": `field "5-2": "5-2" out of range 1-31`,
+	} {
+		_, err := parseCron(spec)
+		c.Assert(err, ErrorMatches, msg)
+	}
+}
+
+func (s *Zuite) TestMaintenance(c *C) {
+	logger := &recordingLogger{}
+	p := newPublisher("", Options{
+		Logger:  logger,
+		Summary: true,
+		MaintenanceWindows: []MaintenanceWindow{
+			{Schedule: "0 2 * * *", Duration: time.Hour, Location: time.UTC},
+			{Schedule: "0 22 * * *", Duration: 8 * time.Hour, FullOnly: true, Location: time.UTC},
+			{Schedule: "0 2 * *"},
+		},
+	})
+	c.Assert(p.windows, HasLen, 2)
+
+	at := func(hour, minute int) time.Time {
+		return time.Date(2024, 3, 4, hour, minute, 30, 0, time.UTC)
+	}
+	c.Assert(p.maintenance(at(21, 59)), Equals, maintenanceNone)
+	c.Assert(p.maintenance(at(22, 0)), Equals, maintenanceFullOnly)
+	c.Assert(p.maintenance(at(2, 59)), Equals, maintenancePaused)
+	c.Assert(p.maintenance(at(3, 0)), Equals, maintenanceFullOnly)
+	c.Assert(p.maintenance(at(6, 0)), Equals, maintenanceNone)
+	c.Assert(logger.lines, DeepEquals, []string{
+		`WARNING: ignoring maintenance window "0 2 * *": expected 5 fields, got 4`,
+		"maintenance window started, only publishing full flushes",
+		"maintenance window started, pausing publishing",
+		"maintenance window started, only publishing full flushes",
+		"maintenance window ended, resuming publishing",
+	})
+}
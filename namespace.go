@@ -0,0 +1,72 @@
+package signalfx
+
+import (
+	"strings"
+
+	metrics "github.com/rcrowley/go-metrics"
+)
+
+// Namespace returns a view of the registry in which all metrics are scoped
+// under the prefix, and published with the dimensions. Metrics registered
+// through the view are stored in the underlying registry, so publishing the
+// underlying registry publishes them:
+//
+// 	checkout := signalfx.Namespace(metrics.DefaultRegistry, "checkout", map[string]string{
+// 		"env": "prod",
+// 	})
+// 	metrics.GetOrRegisterCounter("orders", checkout).Inc(1)
+//
+// results in the counter "checkout.orders" with dimension env=prod.
+func Namespace(r metrics.Registry, prefix string, dims map[string]string) metrics.Registry {
+	n := &namespace{prefix: prefix + ".", dims: dims}
+	return &View{
+		Registry:   r,
+		Filters:    []Filter{FilterFunc(n.contains)},
+		Rename:     n.local,
+		Dimensions: n.localDimensions,
+		Scope:      n.scoped,
+	}
+}
+
+// namespace converts the names of a Namespace view.
+type namespace struct {
+	prefix string
+	dims   map[string]string
+}
+
+// scoped converts a name local to the namespace into the parent's name.
+func (n *namespace) scoped(name string) string {
+	base, dims := parseName(name)
+	return n.prefix + base + encodeDimensions(mergeDimensions(n.dims, dims))
+}
+
+// contains reports whether the parent's name belongs to the namespace.
+func (n *namespace) contains(name string, _ interface{}) bool {
+	base, dims := parseName(name)
+	if !strings.HasPrefix(base, n.prefix) {
+		return false
+	}
+	for key, value := range n.dims {
+		if v, ok := dims[key]; !ok || v != value {
+			return false
+		}
+	}
+	return true
+}
+
+// local converts the parent's name of a metric of the namespace, without
+// dimensions, into the name local to the namespace.
+func (n *namespace) local(base string) string {
+	return strings.TrimPrefix(base, n.prefix)
+}
+
+// localDimensions removes the dimensions of the namespace.
+func (n *namespace) localDimensions(_ string, dims map[string]string) map[string]string {
+	rest := make(map[string]string)
+	for key, value := range dims {
+		if _, ok := n.dims[key]; !ok {
+			rest[key] = value
+		}
+	}
+	return rest
+}
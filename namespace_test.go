@@ -0,0 +1,55 @@
+package signalfx
+
+import (
+	metrics "github.com/rcrowley/go-metrics"
+	. "gopkg.in/check.v1"
+)
+
+func (s *Zuite) TestNamespace(c *C) {
+	r := metrics.NewRegistry()
+	ns := Namespace(r, "checkout", map[string]string{"env": "prod"})
+
+	metrics.GetOrRegisterCounter("orders", ns).Inc(3)
+	metrics.GetOrRegisterCounter(NameWithDimensions("items", map[string]string{"kind": "book"}), ns).Inc(1)
+	metrics.GetOrRegisterCounter("checkout.orders", r)
+
+	c.Assert(r.Get("checkout.orders[env=prod]").(metrics.Counter).Count(), Equals, int64(3))
+	c.Assert(r.Get("checkout.items[env=prod,kind=book]"), NotNil)
+
+	names := make(map[string]bool)
+	ns.Each(func(name string, _ interface{}) {
+		names[name] = true
+	})
+	c.Assert(names, DeepEquals, map[string]bool{"orders": true, "items[kind=book]": true})
+
+	ns.UnregisterAll()
+	c.Assert(r.Get("checkout.orders[env=prod]"), IsNil)
+	c.Assert(r.Get("checkout.orders"), NotNil)
+}
+
+func (s *Zuite) TestMetricToDatapoints_namespaced(c *C) {
+	r := metrics.NewRegistry()
+	metrics.GetOrRegisterCounter("orders", Namespace(r, "checkout", map[string]string{"env": "prod"})).Inc(3)
+
+	p := newPublisher("", Options{})
+	u := p.prepareUpdate()
+	r.Each(u.metricToDatapoints)
+
+	c.Assert(u.ds, HasLen, 1)
+	c.Assert(u.ds[0].Metric, Equals, "checkout.orders")
+	c.Assert(u.ds[0].Dimensions, DeepEquals, map[string]string{"env": "prod"})
+}
+
+func (s *Zuite) TestMetricPrefix(c *C) {
+	r := metrics.NewRegistry()
+	metrics.GetOrRegisterCounter(NameWithDimensions("orders", map[string]string{"env": "prod"}), r).Inc(1)
+	metrics.GetOrRegisterMeter("requests", r).Mark(1)
+
+	batch := Collect(r, Options{MetricPrefix: "payments."})
+	_, ok := batch.Get("payments.orders", map[string]string{"env": "prod"})
+	c.Assert(ok, Equals, true)
+	_, ok = batch.Get("payments.requests.count", nil)
+	c.Assert(ok, Equals, true)
+	_, ok = batch.Get("orders", map[string]string{"env": "prod"})
+	c.Assert(ok, Equals, false)
+}
@@ -0,0 +1,118 @@
+package signalfx
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+
+	metrics "github.com/rcrowley/go-metrics"
+)
+
+// offlineProbeTimeout bounds the connectivity probe made while offline.
+const offlineProbeTimeout = 2 * time.Second
+
+// errOffline fails the flushes skipped while offline, for their datapoints
+// to be buffered.
+var errOffline = errors.New("network unavailable")
+
+// offlineError reports whether the error shows the network is unavailable,
+// as opposed to SignalFX being slow or failing.
+func offlineError(err error) bool {
+	err = cause(err)
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return !dnsErr.IsTimeout
+	}
+	return errors.Is(err, syscall.ENETUNREACH) ||
+		errors.Is(err, syscall.EHOSTUNREACH) ||
+		errors.Is(err, syscall.ENETDOWN)
+}
+
+// online reports whether flushing should be attempted. While offline, it
+// probes connectivity by dialing the endpoint, or the proxy, rather than
+// attempting a flush bound to time out.
+func (p *publisher) online() bool {
+	if !p.offline || p.client == nil {
+		return true
+	}
+	addr, err := p.probeAddr()
+	if err == nil {
+		ctx, cancel := context.WithTimeout(context.Background(), offlineProbeTimeout)
+		defer cancel()
+		var conn net.Conn
+		if conn, err = dialContext(p.opt)(ctx, "tcp", addr); err == nil {
+			conn.Close()
+		}
+	}
+	if err != nil {
+		p.logf(levelVerbose, "still offline: %s", err)
+		return false
+	}
+	p.offline = false
+	p.logf(levelWarning, "network reachable again after %s, resuming publishing", time.Since(p.offlineSince).Round(time.Second))
+	return true
+}
+
+// queueOffline collects the datapoints of a flush skipped while offline into
+// the retry buffer or the spool, if any, as those of a failed flush, to be
+// sent once connectivity returns.
+func (p *publisher) queueOffline(ctx context.Context, r metrics.Registry) {
+	if p.buffer == nil && p.spool == nil {
+		return
+	}
+	u := p.prepareUpdate()
+	u.ctx = ctx
+	u.collect(r)
+	if u.err != nil {
+		return
+	}
+	if u.sums != nil {
+		u.settleSums()
+	}
+	u.commit(func(string) bool { return false })
+	p.buffer.retain(u, errOffline)
+	p.spool.settle(u, errOffline)
+	p.logf(levelVerbose, "offline, buffered %d datapoints", u.stats.Buffered)
+}
+
+// checkOffline records whether the flush failed because the network is
+// unavailable, in which case the next flushes are skipped until
+// connectivity returns.
+func (p *publisher) checkOffline(err error) {
+	if !p.opt.DetectOffline || p.client == nil || p.offline || err == nil || !offlineError(err) {
+		return
+	}
+	p.offline = true
+	p.offlineSince = time.Now()
+	p.logf(levelWarning, "WARNING: network unavailable (%s), skipping flushes until it is reachable again", err)
+}
+
+// probeAddr returns the address to dial to reach the datapoint endpoint:
+// the proxy's if the endpoint is proxied, or else the endpoint's.
+func (p *publisher) probeAddr() (string, error) {
+	u, err := url.Parse(p.client.DatapointEndpoint)
+	if err != nil {
+		return "", err
+	}
+	if t, ok := p.transport.base.(*http.Transport); ok && t.Proxy != nil {
+		proxy, err := t.Proxy(&http.Request{URL: u})
+		if err != nil {
+			return "", err
+		}
+		if proxy != nil {
+			u = proxy
+		}
+	}
+	if port := u.Port(); port != "" {
+		return u.Host, nil
+	}
+	port := "443"
+	if u.Scheme == "http" {
+		port = "80"
+	}
+	return net.JoinHostPort(u.Hostname(), port), nil
+}
@@ -0,0 +1,106 @@
+package signalfx
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"syscall"
+
+	metrics "github.com/rcrowley/go-metrics"
+	"github.com/signalfx/golib/datapoint"
+	"github.com/signalfx/golib/sfxclient"
+	. "gopkg.in/check.v1"
+)
+
+func (s *Zuite) TestOfflineError(c *C) {
+	unreachable := &net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ENETUNREACH)}
+	c.Assert(offlineError(unreachable), Equals, true)
+	c.Assert(offlineError(&net.DNSError{Err: "no such host", IsNotFound: true}), Equals, true)
+	c.Assert(offlineError(&net.DNSError{Err: "i/o timeout", IsTimeout: true}), Equals, false)
+	c.Assert(offlineError(errors.New("invalid status code 503")), Equals, false)
+
+	// The sink annotates the errors of the client.
+	sink := sfxclient.NewHTTPSink()
+	sink.DatapointEndpoint = "http://ingest.invalid/v2/datapoint"
+	err := sink.AddDatapoints(context.Background(), []*datapoint.Datapoint{sfxclient.Gauge("requests", nil, 1)})
+	c.Assert(err, NotNil)
+	c.Assert(offlineError(err), Equals, true)
+}
+
+func (s *Zuite) TestOnline(c *C) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	logger := &recordingLogger{}
+	p := newPublisher("", Options{DetectOffline: true, Logger: logger})
+	c.Assert(p.connect(), IsNil)
+	p.client.DatapointEndpoint = server.URL + "/v2/datapoint"
+
+	p.checkOffline(errors.New("invalid status code 503"))
+	c.Assert(p.offline, Equals, false)
+	p.checkOffline(&net.DNSError{Err: "no such host", IsNotFound: true})
+	c.Assert(p.offline, Equals, true)
+
+	c.Assert(p.online(), Equals, true)
+	c.Assert(p.offline, Equals, false)
+	c.Assert(logger.lines, HasLen, 2)
+	c.Assert(logger.lines[1], Matches, "network reachable again after .*, resuming publishing")
+
+	// Connections are refused once the server is closed.
+	server.Close()
+	p.offline = true
+	c.Assert(p.online(), Equals, false)
+	c.Assert(p.offline, Equals, true)
+}
+
+func (s *Zuite) TestQueueOffline(c *C) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+	sender := &fakeSender{}
+	p := newPublisher("", Options{DetectOffline: true, BufferSize: 10})
+	c.Assert(p.connect(), IsNil)
+	p.client.DatapointEndpoint = server.URL + "/v2/datapoint"
+	p.sender = sender
+	p.offline = true
+
+	r := metrics.NewRegistry()
+	queue := metrics.GetOrRegisterGauge("queue", r)
+	queue.Update(1)
+	c.Assert(p.single(r), IsNil)
+	queue.Update(2)
+	c.Assert(p.single(r), IsNil)
+	c.Assert(sender.sent, HasLen, 0)
+	c.Assert(p.buffer.ds, HasLen, 2)
+
+	// Once online, the values collected meanwhile are sent.
+	p.offline = false
+	c.Assert(p.single(r), IsNil)
+	var values []float64
+	for _, point := range newBatch(sender.sent) {
+		values = append(values, point.Value)
+	}
+	c.Assert(values, DeepEquals, []float64{1, 2, 2})
+}
+
+func (s *Zuite) TestProbeAddr(c *C) {
+	p := newPublisher("", Options{})
+	c.Assert(p.connect(), IsNil)
+	p.transport.base.(*http.Transport).Proxy = nil
+
+	p.client.DatapointEndpoint = "https://ingest.signalfx.com/v2/datapoint"
+	addr, err := p.probeAddr()
+	c.Assert(err, IsNil)
+	c.Assert(addr, Equals, "ingest.signalfx.com:443")
+
+	p.client.DatapointEndpoint = "http://localhost:9080/v2/datapoint"
+	addr, err = p.probeAddr()
+	c.Assert(err, IsNil)
+	c.Assert(addr, Equals, "localhost:9080")
+
+	p.transport.base.(*http.Transport).Proxy = http.ProxyURL(&url.URL{Scheme: "http", Host: "proxy:3128"})
+	addr, err = p.probeAddr()
+	c.Assert(err, IsNil)
+	c.Assert(addr, Equals, "proxy:3128")
+}
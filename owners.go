@@ -0,0 +1,43 @@
+package signalfx
+
+import (
+	"sort"
+	"strings"
+)
+
+// OwnerDimension is the dimension naming the owner of metrics, see
+// Options.Owners.
+const OwnerDimension = "owner"
+
+// owners maps metric name prefixes to their owner, longest prefix first.
+type owners []ownerPrefix
+
+type ownerPrefix struct {
+	prefix string
+	owner  string
+}
+
+func newOwners(byPrefix map[string]string) owners {
+	o := make(owners, 0, len(byPrefix))
+	for prefix, owner := range byPrefix {
+		o = append(o, ownerPrefix{prefix: prefix, owner: owner})
+	}
+	sort.Slice(o, func(i, j int) bool {
+		if len(o[i].prefix) != len(o[j].prefix) {
+			return len(o[i].prefix) > len(o[j].prefix)
+		}
+		return o[i].prefix < o[j].prefix
+	})
+	return o
+}
+
+// owner returns the owner of the metric, as per its longest prefix with an
+// owner, or "" if none.
+func (o owners) owner(name string) string {
+	for _, p := range o {
+		if strings.HasPrefix(name, p.prefix) {
+			return p.owner
+		}
+	}
+	return ""
+}
@@ -0,0 +1,34 @@
+package signalfx
+
+import (
+	metrics "github.com/rcrowley/go-metrics"
+	. "gopkg.in/check.v1"
+)
+
+func (s *Zuite) TestOwners(c *C) {
+	o := newOwners(map[string]string{
+		"http.":          "platform",
+		"http.payments.": "payments",
+		"":               "unowned",
+	})
+	c.Assert(o.owner("http.payments.latency"), Equals, "payments")
+	c.Assert(o.owner("http.requests"), Equals, "platform")
+	c.Assert(o.owner("jobs"), Equals, "unowned")
+	c.Assert(owners(nil).owner("jobs"), Equals, "")
+}
+
+func (s *Zuite) TestMetricToDatapoints_owners(c *C) {
+	p := newPublisher("", Options{Owners: map[string]string{"http.": "platform"}})
+	u := p.prepareUpdate()
+	u.metricToDatapoints("http.requests", metrics.NewCounter())
+	u.metricToDatapoints("http.errors[owner=payments]", metrics.NewCounter())
+	u.metricToDatapoints("jobs", metrics.NewCounter())
+
+	batch := newBatch(u.ds)
+	_, ok := batch.Get("http.requests", map[string]string{OwnerDimension: "platform"})
+	c.Assert(ok, Equals, true)
+	_, ok = batch.Get("http.errors", map[string]string{OwnerDimension: "payments"})
+	c.Assert(ok, Equals, true)
+	_, ok = batch.Get("jobs", nil)
+	c.Assert(ok, Equals, true)
+}
@@ -0,0 +1,48 @@
+package signalfx
+
+import "sync"
+
+// pauser silences the publisher, on demand.
+type pauser struct {
+	mu      sync.Mutex
+	paused  bool
+	pausing bool
+	resumed bool
+}
+
+func (s *pauser) pause() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.paused {
+		s.paused = true
+		s.pausing = true
+	}
+}
+
+func (s *pauser) resume() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.paused {
+		s.paused = false
+		s.resumed = true
+	}
+}
+
+func (s *pauser) isPaused() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.paused
+}
+
+// flushing reports whether the publisher should flush, whether it was
+// paused since the last call, and whether it was resumed since the last
+// flush, in which case the flush must be full. The transitions are reported
+// to the run loop, for it to log them, rather than logged by the callers of
+// pause and resume.
+func (s *pauser) flushing() (flush, paused, resumed bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	paused, s.pausing = s.pausing, false
+	resumed, s.resumed = s.resumed, false
+	return !s.paused, paused, resumed
+}
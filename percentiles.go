@@ -0,0 +1,44 @@
+package signalfx
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// defaultPercentiles are the percentiles of histograms and timers sent by
+// default.
+var defaultPercentiles = []float64{0.5, 0.75, 0.95, 0.99, 0.999}
+
+// percentiles are the percentiles of histograms and timers sent, with the
+// suffixes of their submetrics.
+type percentiles struct {
+	quantiles []float64
+	suffixes  []string
+}
+
+// newPercentiles returns the Percentiles of the options, dropping those out
+// of range, or the default ones if none is configured.
+func newPercentiles(opt Options, warnf func(format string, v ...interface{})) percentiles {
+	quantiles := opt.Percentiles
+	if quantiles == nil {
+		quantiles = defaultPercentiles
+	}
+	var p percentiles
+	for _, q := range quantiles {
+		if q <= 0 || q > 1 {
+			warnf("WARNING: ignoring percentile %g, not within (0, 1]", q)
+			continue
+		}
+		p.quantiles = append(p.quantiles, q)
+		p.suffixes = append(p.suffixes, percentileSuffix(q))
+	}
+	return p
+}
+
+// percentileSuffix returns the suffix of the submetric of the percentile,
+// e.g. "99-percentile" for 0.99 and "999-percentile" for 0.999.
+func percentileSuffix(q float64) string {
+	percent := strconv.FormatFloat(math.Round(q*1e6)/1e4, 'f', -1, 64)
+	return strings.Replace(percent, ".", "", 1) + "-percentile"
+}
@@ -0,0 +1,37 @@
+package signalfx
+
+import (
+
+	metrics "github.com/rcrowley/go-metrics"
+	. "gopkg.in/check.v1"
+)
+
+func (s *Zuite) TestPercentileSuffix(c *C) {
+	for q, suffix := range map[float64]string{
+		0.5:    "50-percentile",
+		0.9:    "90-percentile",
+		0.999:  "999-percentile",
+		0.9999: "9999-percentile",
+		1:      "100-percentile",
+	} {
+		c.Assert(percentileSuffix(q), Equals, suffix)
+	}
+}
+
+func (s *Zuite) TestPercentiles(c *C) {
+	r := metrics.NewRegistry()
+	metrics.GetOrRegisterTimer("latency", r).Update(1)
+
+	logger := &recordingLogger{}
+	batch := Collect(r, Options{
+		Percentiles:    []float64{0.9, 0.999, 1.5},
+		DropSubmetrics: map[string][]string{"timer": {"one-minute", "five-minute", "fifteen-minute", "mean-rate"}},
+	})
+	c.Assert(batchNames(batch), DeepEquals, []string{
+		"latency.90-percentile", "latency.999-percentile", "latency.count", "latency.max", "latency.mean",
+		"latency.min", "latency.std-dev",
+	})
+
+	newPublisher("", Options{Logger: logger, Percentiles: []float64{0, 0.99}})
+	c.Assert(logger.lines, DeepEquals, []string{"WARNING: ignoring percentile 0, not within (0, 1]"})
+}
@@ -0,0 +1,106 @@
+package signalfx
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+)
+
+// CachedValues are the values of gauges last sent to SignalFX, by series,
+// which the publisher diffs the next values against. Counters are not
+// saved, since they restart from zero with the process.
+type CachedValues struct {
+	// Time is when the values were saved.
+	Time time.Time `json:"time"`
+
+	Gauges        map[string]int64   `json:"gauges,omitempty"`
+	GaugesFloat64 map[string]float64 `json:"gauges_f,omitempty"`
+}
+
+// CacheStore persists the values last sent across restarts, see
+// Options.CacheStore.
+type CacheStore interface {
+	// Load returns the values last saved, or no values if none were.
+	Load() (CachedValues, error)
+
+	// Save saves the values. The values must not be retained after Save
+	// returns.
+	Save(values CachedValues) error
+}
+
+// FileCacheStore returns a CacheStore saving the values as JSON to the
+// file at path.
+func FileCacheStore(path string) CacheStore {
+	return fileCacheStore(path)
+}
+
+type fileCacheStore string
+
+func (path fileCacheStore) Load() (CachedValues, error) {
+	var values CachedValues
+	f, err := os.Open(string(path))
+	if os.IsNotExist(err) {
+		return values, nil
+	} else if err != nil {
+		return values, err
+	}
+	defer f.Close()
+	err = json.NewDecoder(f).Decode(&values)
+	return values, err
+}
+
+func (path fileCacheStore) Save(values CachedValues) error {
+	tmp := string(path) + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if err := json.NewEncoder(f).Encode(values); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, string(path))
+}
+
+// restoreCaches restores the values last sent from the CacheStore, unless
+// saved longer than FullFrequency ago, since a full flush is then due.
+func (p *publisher) restoreCaches() {
+	values, err := p.opt.CacheStore.Load()
+	if err != nil {
+		p.logf(levelWarning, "WARNING: restoring the values last sent: %s", err)
+		return
+	}
+	if values.Time.IsZero() {
+		return
+	}
+	if age := time.Since(values.Time); p.opt.FullFrequency > 0 && age > p.opt.FullFrequency {
+		p.logf(levelVerbose, "not restoring the values last sent %s ago", age.Round(time.Second))
+		return
+	}
+	for key, gauge := range values.Gauges {
+		p.last.gauges[key] = gauge
+	}
+	for key, gaugeF := range values.GaugesFloat64 {
+		p.last.gauges_f[key] = gaugeF
+	}
+	p.logf(levelInfo, "restored the values last sent of %d series",
+		len(values.Gauges)+len(values.GaugesFloat64))
+}
+
+// saveCaches saves the values last sent to the CacheStore, if set.
+func (p *publisher) saveCaches() {
+	if p.opt.CacheStore == nil {
+		return
+	}
+	err := p.opt.CacheStore.Save(CachedValues{
+		Time:          time.Now(),
+		Gauges:        p.last.gauges,
+		GaugesFloat64: p.last.gauges_f,
+	})
+	if err != nil {
+		p.logf(levelWarning, "WARNING: saving the values last sent: %s", err)
+	}
+}
@@ -0,0 +1,70 @@
+package signalfx
+
+import (
+	"errors"
+	"path/filepath"
+	"time"
+
+	metrics "github.com/rcrowley/go-metrics"
+	. "gopkg.in/check.v1"
+)
+
+func (s *Zuite) TestCacheStore_restart(c *C) {
+	store := FileCacheStore(filepath.Join(c.MkDir(), "cache.json"))
+	r := metrics.NewRegistry()
+	metrics.GetOrRegisterCounter("requests", r).Inc(3)
+	metrics.GetOrRegisterGaugeFloat64("ratio", r).Update(0.5)
+
+	sender := &fakeSender{}
+	pub := NewWithSender(r, sender, Options{CacheStore: store, DiffFrequency: time.Hour, FullFrequency: time.Minute})
+	c.Assert(pub.p.single(r), IsNil)
+	c.Assert(sender.sent, HasLen, 2)
+
+	// Diff flushes do not save the values sent, closing does.
+	values, err := store.Load()
+	c.Assert(err, IsNil)
+	c.Assert(values.Time.IsZero(), Equals, true)
+	pub.Start()
+	pub.Close()
+	values, err = store.Load()
+	c.Assert(err, IsNil)
+	c.Assert(values.GaugesFloat64, DeepEquals, map[string]float64{"ratio": 0.5})
+
+	// After a restart, unchanged gauges are not sent again, while counters,
+	// which restart with the process, are.
+	r = metrics.NewRegistry()
+	metrics.GetOrRegisterCounter("requests", r).Inc(3)
+	metrics.GetOrRegisterGaugeFloat64("ratio", r).Update(0.5)
+	p, sender := newTestPublisher(Options{CacheStore: store, FullFrequency: time.Minute})
+	c.Assert(p.single(r), IsNil)
+	c.Assert(sender.sent, HasLen, 1)
+	c.Assert(sender.sent[0].Metric, Equals, "requests")
+}
+
+func (s *Zuite) TestCacheStore_stale(c *C) {
+	store := FileCacheStore(filepath.Join(c.MkDir(), "cache.json"))
+	c.Assert(store.Save(CachedValues{
+		Time:   time.Now().Add(-2 * time.Minute),
+		Gauges: map[string]int64{"connections": 3},
+	}), IsNil)
+
+	p := newPublisher("", Options{CacheStore: store, FullFrequency: time.Minute})
+	c.Assert(p.last.gauges, HasLen, 0)
+	p = newPublisher("", Options{CacheStore: store, FullFrequency: time.Hour})
+	c.Assert(p.last.gauges, DeepEquals, map[string]int64{"connections": 3})
+}
+
+type failingCacheStore struct{}
+
+func (failingCacheStore) Load() (CachedValues, error) { return CachedValues{}, errors.New("corrupt") }
+func (failingCacheStore) Save(CachedValues) error     { return errors.New("read-only") }
+
+func (s *Zuite) TestCacheStore_errors(c *C) {
+	logger := &recordingLogger{}
+	p := newPublisher("", Options{CacheStore: failingCacheStore{}, Logger: logger})
+	p.saveCaches()
+	c.Assert(logger.lines, DeepEquals, []string{
+		"WARNING: restoring the values last sent: corrupt",
+		"WARNING: saving the values last sent: read-only",
+	})
+}
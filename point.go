@@ -0,0 +1,96 @@
+package signalfx
+
+import (
+	"time"
+
+	metrics "github.com/rcrowley/go-metrics"
+	"github.com/signalfx/golib/datapoint"
+)
+
+// PointType is the SignalFX metric type of a Point.
+type PointType string
+
+// Metric types.
+const (
+	PointGauge             PointType = "gauge"
+	PointCounter           PointType = "counter"
+	PointCumulativeCounter PointType = "cumulative_counter"
+)
+
+// Point is a datapoint sent to SignalFX. Time is zero unless Timestamps is
+// set.
+type Point struct {
+	Name  string
+	Type  PointType
+	Value float64
+	Dims  map[string]string
+	Time  time.Time
+}
+
+// Batch is the datapoints sent by a flush.
+type Batch []Point
+
+// Get returns the point of the series with the name and dimensions, the
+// default dimensions included.
+func (b Batch) Get(name string, dims map[string]string) (Point, bool) {
+	key := name + encodeDimensions(dims)
+	for _, p := range b {
+		if p.Name+encodeDimensions(p.Dims) == key {
+			return p, true
+		}
+	}
+	return Point{}, false
+}
+
+// Collect returns the datapoints a full flush of the registry with the
+// options sends to SignalFX, without sending them. It is meant for tests
+// asserting on the published metrics:
+//
+// 	batch := signalfx.Collect(registry, signalfx.Options{})
+// 	point, ok := batch.Get("requests", map[string]string{"env": "prod"})
+func Collect(r metrics.Registry, opt Options) Batch {
+	return newBatch(dryRun(r, opt).ds)
+}
+
+// dryRun converts the registry as a full flush with the options does, with
+// a publisher which logs nothing and is not attached to anything.
+func dryRun(r metrics.Registry, opt Options) *update {
+	setDefaults(&opt)
+	opt.Logger = nil
+	opt.ErrorLogger = nil
+	opt.SelfMetrics = nil
+	opt.PrometheusHandler = nil
+	opt.Admin = nil
+	opt.CacheStore = nil
+	opt.RuntimeMetrics = false
+	u := newPublisher("", opt).prepareUpdate()
+	u.collect(r)
+	return u
+}
+
+// newBatch converts datapoints into a Batch.
+func newBatch(ds []*datapoint.Datapoint) Batch {
+	batch := make(Batch, 0, len(ds))
+	for _, d := range ds {
+		batch = append(batch, newPoint(d))
+	}
+	return batch
+}
+
+// newPoint converts a datapoint into a Point.
+func newPoint(d *datapoint.Datapoint) Point {
+	var value float64
+	switch v := d.Value.(type) {
+	case datapoint.IntValue:
+		value = float64(v.Int())
+	case datapoint.FloatValue:
+		value = v.Float()
+	}
+	return Point{
+		Name:  d.Metric,
+		Type:  PointType(metricTypeName(d.MetricType)),
+		Value: value,
+		Dims:  d.Dimensions,
+		Time:  d.Timestamp,
+	}
+}
@@ -0,0 +1,54 @@
+package signalfx
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	metrics "github.com/rcrowley/go-metrics"
+	. "gopkg.in/check.v1"
+)
+
+func (s *Zuite) TestCollect(c *C) {
+	r := metrics.NewRegistry()
+	metrics.GetOrRegisterCounter(NameWithDimensions("requests", map[string]string{"env": "prod"}), r).Inc(3)
+	metrics.GetOrRegisterGaugeFloat64("load", r).Update(0.5)
+
+	batch := Collect(r, Options{})
+	c.Assert(batch, HasLen, 2)
+
+	point, ok := batch.Get("requests", map[string]string{"env": "prod"})
+	c.Assert(ok, Equals, true)
+	c.Assert(point, DeepEquals, Point{
+		Name:  "requests",
+		Type:  PointCounter,
+		Value: 3,
+		Dims:  map[string]string{"env": "prod"},
+	})
+	point, ok = batch.Get("load", nil)
+	c.Assert(ok, Equals, true)
+	c.Assert(point.Type, Equals, PointGauge)
+	c.Assert(point.Value, Equals, 0.5)
+
+	_, ok = batch.Get("requests", nil)
+	c.Assert(ok, Equals, false)
+}
+
+func (s *Zuite) TestFlush_onBatch(c *C) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`"OK"`))
+	}))
+	defer server.Close()
+
+	var batches []Batch
+	p := newPublisher("", Options{OnBatch: func(b Batch) {
+		batches = append(batches, b)
+	}})
+	c.Assert(p.connect(), IsNil)
+	p.client.DatapointEndpoint = server.URL
+
+	u := p.prepareUpdate()
+	u.appendIfGaugeChanged("queue", 7)
+	c.Assert(u.flush(), IsNil)
+
+	c.Assert(batches, DeepEquals, []Batch{{{Name: "queue", Type: PointGauge, Value: 7}}})
+}
@@ -0,0 +1,251 @@
+package signalfx
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/signalfx/golib/datapoint"
+)
+
+// Priority orders metrics under degradation: when not all datapoints can be
+// sent, those of the lowest priority are shed first.
+type Priority int
+
+// Priorities. PriorityCritical datapoints, e.g. of SLO metrics, are never
+// shed.
+const (
+	PriorityLow      Priority = -1
+	PriorityNormal   Priority = 0
+	PriorityHigh     Priority = 1
+	PriorityCritical Priority = 2
+)
+
+// PriorityRule sets the priority of the metrics whose name matches the
+// pattern, as per path.Match, e.g. "slo.*".
+type PriorityRule struct {
+	Pattern  string
+	Priority Priority
+}
+
+// priorities selects the priority of metrics, by name.
+type priorities struct {
+	rules []PriorityRule
+
+	// matched caches the priority of each name.
+	matched map[string]Priority
+}
+
+// newPriorities creates the selection of priorities configured in the
+// options, dropping the rules with invalid patterns, or returns nil if no
+// rule is configured.
+func newPriorities(opt Options, warnf func(format string, v ...interface{})) *priorities {
+	if len(opt.Priorities) == 0 {
+		return nil
+	}
+	p := &priorities{matched: make(map[string]Priority)}
+	for _, rule := range opt.Priorities {
+		if _, err := path.Match(rule.Pattern, ""); err != nil {
+			warnf("WARNING: ignoring priority of invalid pattern %q: %s", rule.Pattern, err)
+			continue
+		}
+		p.rules = append(p.rules, rule)
+	}
+	return p
+}
+
+// priority returns the priority of the metric named name, PriorityNormal if
+// no rule matches it.
+func (p *priorities) priority(name string) Priority {
+	priority, ok := p.matched[name]
+	if !ok {
+		for _, rule := range p.rules {
+			if ok, _ := path.Match(rule.Pattern, name); ok {
+				priority = rule.Priority
+				break
+			}
+		}
+		p.matched[name] = priority
+	}
+	return priority
+}
+
+// shed keeps at most capacity datapoints, shedding those of the lowest
+// priorities first, and returns the datapoints kept, in their order, with
+// the number shed. PriorityCritical datapoints are kept beyond capacity.
+func (p *priorities) shed(ds []*datapoint.Datapoint, capacity int) ([]*datapoint.Datapoint, int) {
+	return shedRanked(ds, capacity, func(d *datapoint.Datapoint) int {
+		return int(p.priority(d.Metric))
+	}, p.critical)
+}
+
+// critical reports whether the datapoint is PriorityCritical. A nil
+// *priorities has no critical datapoints.
+func (p *priorities) critical(d *datapoint.Datapoint) bool {
+	return p != nil && p.priority(d.Metric) >= PriorityCritical
+}
+
+// shedRanked keeps at most capacity datapoints, shedding those of the
+// lowest rank first, and returns the datapoints kept, in their order, with
+// the number shed. Datapoints which are critical are kept beyond capacity.
+func shedRanked(ds []*datapoint.Datapoint, capacity int, rank func(*datapoint.Datapoint) int, critical func(*datapoint.Datapoint) bool) ([]*datapoint.Datapoint, int) {
+	if len(ds) <= capacity {
+		return ds, 0
+	}
+	if capacity < 0 {
+		capacity = 0
+	}
+	order := make([]int, len(ds))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return rank(ds[order[i]]) > rank(ds[order[j]])
+	})
+	keep := make([]bool, len(ds))
+	for n, i := range order {
+		keep[i] = n < capacity || critical(ds[i])
+	}
+	kept := make([]*datapoint.Datapoint, 0, capacity)
+	for i, d := range ds {
+		if keep[i] {
+			kept = append(kept, d)
+		}
+	}
+	return kept, len(ds) - len(kept)
+}
+
+// Kinds of datapoints, in the order they are shed when exceeding the
+// MaxDatapointsPerMinute.
+const (
+	kindPercentile = iota
+	kindRate
+	kindGauge
+	kindCounter
+)
+
+var kindNames = []string{"percentiles", "rates", "gauges", "counters"}
+
+// kindKey is the key of the kind of a datapoint in its Meta, tagged when
+// the datapoint of a submetric is created, see submetrics.
+type kindKey struct{}
+
+// datapointKind returns the kind the datapoint was tagged with or else,
+// e.g. for metrics which are not submetrics, the kind of its type.
+func datapointKind(d *datapoint.Datapoint) int {
+	if kind, ok := d.Meta[kindKey{}].(int); ok {
+		return kind
+	}
+	if d.MetricType == datapoint.Gauge {
+		return kindGauge
+	}
+	return kindCounter
+}
+
+// tagKind tags the datapoint with its kind, see datapointKind.
+func tagKind(d *datapoint.Datapoint, kind int) {
+	if d.Meta == nil {
+		d.Meta = make(map[interface{}]interface{})
+	}
+	d.Meta[kindKey{}] = kind
+}
+
+// shedOverBudget sheds the datapoints of the update exceeding what remains
+// of the DPM budget, when priorities are configured, and of the
+// MaxDatapointsPerMinute.
+func (u *update) shedOverBudget(now time.Time) {
+	if u.p.budget == nil {
+		return
+	}
+	sent := u.p.budget.sent(now)
+	if u.p.priorities != nil && u.p.opt.DPMBudget > 0 {
+		u.shedTo(u.p.opt.DPMBudget-sent, fmt.Sprintf("DPM budget of %d exceeded", u.p.opt.DPMBudget))
+	}
+	if max := u.p.opt.MaxDatapointsPerMinute; max > 0 {
+		u.shedOverLimit(max - sent)
+	}
+}
+
+// shedOverLimit sheds datapoints of the update beyond the capacity, see
+// MaxDatapointsPerMinute, logging the kinds of datapoints shed.
+func (u *update) shedOverLimit(capacity int) {
+	p := u.p.priorities
+	kept, shed := shedRanked(u.ds, capacity, func(d *datapoint.Datapoint) int {
+		rank := datapointKind(d)
+		if p != nil {
+			rank += int(p.priority(d.Metric)) * len(kindNames)
+		}
+		return rank
+	}, p.critical)
+	if shed == 0 {
+		return
+	}
+	byKind := make([]int, len(kindNames))
+	u.drop(kept, func(d *datapoint.Datapoint) {
+		byKind[datapointKind(d)]++
+	})
+	var kinds []string
+	for kind, n := range byKind {
+		if n != 0 {
+			kinds = append(kinds, fmt.Sprintf("%s=%d", kindNames[kind], n))
+		}
+	}
+	u.stats.Shed += shed
+	u.p.logf(levelWarning, "WARNING: limit of %d datapoints per minute reached, shed %d datapoints (%s)",
+		u.p.opt.MaxDatapointsPerMinute, shed, strings.Join(kinds, ", "))
+}
+
+// shedWhileProbing sheds the PriorityLow datapoints of the flush probing
+// whether SignalFX recovered, see CircuitHalfOpen, when priorities are
+// configured.
+func (u *update) shedWhileProbing() {
+	if u.p.priorities == nil || u.p.breaker == nil || u.p.breaker.state != CircuitHalfOpen {
+		return
+	}
+	capacity := 0
+	for _, d := range u.ds {
+		if u.p.priorities.priority(d.Metric) > PriorityLow {
+			capacity++
+		}
+	}
+	u.shedTo(capacity, "probing SignalFX")
+}
+
+// shedTo sheds datapoints of the update beyond the capacity, lowest
+// priority first. The last values of the series shed are left as they are,
+// for their changes to be sent later.
+func (u *update) shedTo(capacity int, reason string) {
+	kept, shed := u.p.priorities.shed(u.ds, capacity)
+	if shed == 0 {
+		return
+	}
+	u.drop(kept, func(*datapoint.Datapoint) {})
+	u.stats.Shed += shed
+	u.p.logf(levelWarning, "WARNING: %s, shed %d low priority datapoints", reason, shed)
+}
+
+// drop keeps only the datapoints kept in the update, forgetting the
+// changes of the others, which are passed to dropped.
+func (u *update) drop(kept []*datapoint.Datapoint, dropped func(*datapoint.Datapoint)) {
+	sent := make(map[*datapoint.Datapoint]bool, len(kept))
+	for _, d := range kept {
+		sent[d] = true
+	}
+	for _, d := range u.ds {
+		if !sent[d] {
+			u.forget(u.keys[d])
+			dropped(d)
+		}
+	}
+	u.ds = kept
+}
+
+// forget drops the change of the series from the update, such that it is
+// not committed.
+func (u *update) forget(key string) {
+	delete(u.changes.counters, key)
+	delete(u.changes.gauges, key)
+	delete(u.changes.gauges_f, key)
+}
@@ -0,0 +1,79 @@
+package signalfx
+
+import (
+	"sort"
+	"time"
+
+	metrics "github.com/rcrowley/go-metrics"
+	"github.com/signalfx/golib/datapoint"
+	. "gopkg.in/check.v1"
+)
+
+func (s *Zuite) TestPriorities_shed(c *C) {
+	p := newPriorities(Options{Priorities: []PriorityRule{
+		{Pattern: "slo.*", Priority: PriorityCritical},
+		{Pattern: "debug.*", Priority: PriorityLow},
+		{Pattern: "[", Priority: PriorityHigh},
+	}}, func(string, ...interface{}) {})
+	c.Assert(p.rules, HasLen, 2)
+
+	var ds []*datapoint.Datapoint
+	for _, name := range []string{"debug.a", "slo.a", "requests", "debug.b", "slo.b"} {
+		ds = append(ds, datapoint.New(name, nil, datapoint.NewIntValue(1), datapoint.Count, time.Time{}))
+	}
+	names := func(ds []*datapoint.Datapoint) (names []string) {
+		for _, d := range ds {
+			names = append(names, d.Metric)
+		}
+		return names
+	}
+
+	kept, shed := p.shed(ds, 10)
+	c.Assert(shed, Equals, 0)
+	c.Assert(kept, HasLen, 5)
+
+	kept, shed = p.shed(ds, 4)
+	c.Assert(shed, Equals, 1)
+	c.Assert(names(kept), DeepEquals, []string{"debug.a", "slo.a", "requests", "slo.b"})
+
+	// Critical datapoints are kept beyond capacity.
+	kept, shed = p.shed(ds, 1)
+	c.Assert(shed, Equals, 3)
+	c.Assert(names(kept), DeepEquals, []string{"slo.a", "slo.b"})
+
+	kept, shed = p.shed(ds, -3)
+	c.Assert(shed, Equals, 3)
+	c.Assert(names(kept), DeepEquals, []string{"slo.a", "slo.b"})
+}
+
+func (s *Zuite) TestPriorities_overBudget(c *C) {
+	p, sender := newTestPublisher(Options{
+		DPMBudget:  3,
+		Priorities: []PriorityRule{{Pattern: "slo.*", Priority: PriorityCritical}, {Pattern: "debug", Priority: PriorityLow}},
+	})
+
+	r := metrics.NewRegistry()
+	metrics.GetOrRegisterCounter("debug", r).Inc(1)
+	metrics.GetOrRegisterCounter("requests", r).Inc(1)
+	metrics.GetOrRegisterCounter("slo.errors", r).Inc(1)
+	c.Assert(p.single(r), IsNil)
+	c.Assert(sender.sent, HasLen, 3)
+
+	// The budget is spent for the minute: only critical datapoints flow,
+	// the others being sent once there is room.
+	sender.sent = nil
+	var stats FlushStats
+	p.opt.OnFlush = func(s FlushStats) { stats = s }
+	metrics.GetOrRegisterCounter("debug", r).Inc(1)
+	metrics.GetOrRegisterCounter("requests", r).Inc(1)
+	metrics.GetOrRegisterCounter("slo.errors", r).Inc(1)
+	c.Assert(p.single(r), IsNil)
+	var sent []string
+	for _, d := range sender.sent {
+		sent = append(sent, d.Metric)
+	}
+	sort.Strings(sent)
+	c.Assert(sent, DeepEquals, []string{"slo.errors"})
+	c.Assert(stats.Shed, Equals, 2)
+	c.Assert(p.last.counters["requests"], Equals, int64(1))
+}
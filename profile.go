@@ -0,0 +1,43 @@
+package signalfx
+
+import (
+	"runtime/metrics"
+	"time"
+)
+
+// flushProfile is the cost of a flush, see Options.Profile.
+type flushProfile struct {
+	samples []metrics.Sample
+	cpu     time.Duration
+	cpuOK   bool
+}
+
+// startProfile starts measuring the cost of a flush.
+func startProfile() *flushProfile {
+	f := &flushProfile{samples: []metrics.Sample{
+		{Name: "/gc/heap/allocs:bytes"},
+		{Name: "/gc/heap/allocs:objects"},
+	}}
+	metrics.Read(f.samples)
+	f.cpu, f.cpuOK = processCPU()
+	return f
+}
+
+// stop records in the stats the allocations and CPU time since the profile
+// started.
+func (f *flushProfile) stop(stats *FlushStats) {
+	before := []uint64{sampleUint64(f.samples[0]), sampleUint64(f.samples[1])}
+	metrics.Read(f.samples)
+	stats.AllocBytes = int64(sampleUint64(f.samples[0]) - before[0])
+	stats.Allocs = int64(sampleUint64(f.samples[1]) - before[1])
+	if cpu, ok := processCPU(); ok && f.cpuOK {
+		stats.CPU = cpu - f.cpu
+	}
+}
+
+func sampleUint64(s metrics.Sample) uint64 {
+	if s.Value.Kind() != metrics.KindUint64 {
+		return 0
+	}
+	return s.Value.Uint64()
+}
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package signalfx
+
+import "time"
+
+// processCPU is not supported on this platform.
+func processCPU() (time.Duration, bool) {
+	return 0, false
+}
@@ -0,0 +1,21 @@
+package signalfx
+
+import (
+	metrics "github.com/rcrowley/go-metrics"
+	. "gopkg.in/check.v1"
+)
+
+func (s *Zuite) TestSingle_profile(c *C) {
+	var stats FlushStats
+	p := newPublisher("", Options{Profile: true, OnFlush: func(s FlushStats) { stats = s }})
+	p.sender = &fakeSender{}
+
+	r := metrics.NewRegistry()
+	for _, name := range []string{"a", "b", "c"} {
+		metrics.GetOrRegisterTimer(name, r).Update(1)
+	}
+	c.Assert(p.single(r), IsNil)
+	c.Assert(stats.AllocBytes > 0, Equals, true)
+	c.Assert(stats.Allocs > 0, Equals, true)
+	c.Assert(stats.CPU >= 0, Equals, true)
+}
@@ -0,0 +1,17 @@
+//go:build unix
+
+package signalfx
+
+import (
+	"syscall"
+	"time"
+)
+
+// processCPU returns the user and system CPU time used by the process.
+func processCPU() (time.Duration, bool) {
+	var usage syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &usage); err != nil {
+		return 0, false
+	}
+	return time.Duration(usage.Utime.Nano() + usage.Stime.Nano()), true
+}
@@ -0,0 +1,180 @@
+package signalfx
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	metrics "github.com/rcrowley/go-metrics"
+)
+
+// PrometheusHandler serves the values last sent to SignalFX, and the
+// publisher's self-metrics, in the Prometheus text exposition format. This
+// lets operators cross-check what the bridge believes it sent against what
+// SignalFX shows:
+//
+// 	handler := signalfx.NewPrometheusHandler()
+// 	http.Handle("/metrics", handler)
+// 	go signalfx.PublishToSignalFx(metrics.DefaultRegistry, "<auth_token>", signalfx.Options{
+// 		PrometheusHandler: handler,
+// 	})
+type PrometheusHandler struct {
+	mu          sync.Mutex
+	samples     map[string]promSample
+	self        metrics.Registry
+	defaultDims map[string]string
+}
+
+type promSample struct {
+	counter bool
+	value   float64
+}
+
+// NewPrometheusHandler creates a handler, to be set in Options.
+func NewPrometheusHandler() *PrometheusHandler {
+	return &PrometheusHandler{samples: make(map[string]promSample)}
+}
+
+func (h *PrometheusHandler) attach(self metrics.Registry, defaultDims map[string]string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.self = self
+	h.defaultDims = defaultDims
+}
+
+// record stores the values successfully sent by the update.
+func (h *PrometheusHandler) record(u *update) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for key, counter := range u.changes.counters {
+		h.samples[key] = promSample{counter: true, value: float64(counter)}
+	}
+	for key, gauge := range u.changes.gauges {
+		h.samples[key] = promSample{value: float64(gauge)}
+	}
+	for key, gaugeF := range u.changes.gauges_f {
+		h.samples[key] = promSample{value: gaugeF}
+	}
+}
+
+func (h *PrometheusHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	w.Write(h.exposition())
+}
+
+// exposition renders the last-sent values and the self-metrics.
+func (h *PrometheusHandler) exposition() []byte {
+	h.mu.Lock()
+	keys := make([]string, 0, len(h.samples))
+	for key := range h.samples {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var buf bytes.Buffer
+	lastName := ""
+	for _, key := range keys {
+		sample := h.samples[key]
+		name, dims := parseName(key)
+		name = promName(name)
+		if name != lastName {
+			kind := "gauge"
+			if sample.counter {
+				kind = "counter"
+			}
+			fmt.Fprintf(&buf, "# TYPE %s %s\n", name, kind)
+			lastName = name
+		}
+		writePromSample(&buf, name, mergeDimensions(h.defaultDims, dims), sample.value)
+	}
+	self := h.self
+	h.mu.Unlock()
+
+	if self != nil {
+		writePromRegistry(&buf, self)
+	}
+	return buf.Bytes()
+}
+
+// writePromRegistry renders the counters, gauges, histograms, meters and
+// timers of a registry.
+func writePromRegistry(buf *bytes.Buffer, r metrics.Registry) {
+	var names []string
+	all := make(map[string]interface{})
+	r.Each(func(name string, i interface{}) {
+		names = append(names, name)
+		all[name] = i
+	})
+	sort.Strings(names)
+	for _, name := range names {
+		promName := promName(name)
+		switch metric := all[name].(type) {
+		case metrics.Counter:
+			fmt.Fprintf(buf, "# TYPE %s counter\n", promName)
+			writePromSample(buf, promName, nil, float64(metric.Count()))
+		case metrics.Gauge:
+			fmt.Fprintf(buf, "# TYPE %s gauge\n", promName)
+			writePromSample(buf, promName, nil, float64(metric.Value()))
+		case metrics.GaugeFloat64:
+			fmt.Fprintf(buf, "# TYPE %s gauge\n", promName)
+			writePromSample(buf, promName, nil, metric.Value())
+		case metrics.Histogram:
+			h := metric.Snapshot()
+			writePromSummary(buf, promName, h.Percentiles(promQuantiles), h.Sum(), h.Count())
+		case metrics.Meter:
+			m := metric.Snapshot()
+			fmt.Fprintf(buf, "# TYPE %s_total counter\n", promName)
+			writePromSample(buf, promName+"_total", nil, float64(m.Count()))
+			fmt.Fprintf(buf, "# TYPE %s_rate1m gauge\n", promName)
+			writePromSample(buf, promName+"_rate1m", nil, m.Rate1())
+		case metrics.Timer:
+			t := metric.Snapshot()
+			writePromSummary(buf, promName, t.Percentiles(promQuantiles), t.Sum(), t.Count())
+		}
+	}
+}
+
+// promQuantiles are the quantiles of the summaries of histograms and timers.
+var promQuantiles = []float64{0.5, 0.99}
+
+func writePromSummary(buf *bytes.Buffer, name string, ps []float64, sum, count int64) {
+	fmt.Fprintf(buf, "# TYPE %s summary\n", name)
+	for i, q := range promQuantiles {
+		writePromSample(buf, name, map[string]string{"quantile": strconv.FormatFloat(q, 'g', -1, 64)}, ps[i])
+	}
+	writePromSample(buf, name+"_sum", nil, float64(sum))
+	writePromSample(buf, name+"_count", nil, float64(count))
+}
+
+func writePromSample(buf *bytes.Buffer, name string, labels map[string]string, value float64) {
+	buf.WriteString(name)
+	if len(labels) != 0 {
+		keys := make([]string, 0, len(labels))
+		for key := range labels {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		buf.WriteByte('{')
+		for i, key := range keys {
+			if i != 0 {
+				buf.WriteByte(',')
+			}
+			fmt.Fprintf(buf, "%s=%s", promName(key), strconv.Quote(labels[key]))
+		}
+		buf.WriteByte('}')
+	}
+	fmt.Fprintf(buf, " %s\n", strconv.FormatFloat(value, 'g', -1, 64))
+}
+
+// promName converts a metric name into a valid Prometheus name.
+func promName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == ':' {
+			return r
+		}
+		return '_'
+	}, name)
+}
@@ -0,0 +1,54 @@
+package signalfx
+
+import (
+	"net/http/httptest"
+	"regexp"
+
+	metrics "github.com/rcrowley/go-metrics"
+	. "gopkg.in/check.v1"
+)
+
+func (s *Zuite) TestPrometheusHandler(c *C) {
+	self := metrics.NewRegistry()
+	metrics.GetOrRegisterCounter("signalfx.bytes_sent", self).Inc(42)
+	metrics.GetOrRegisterGaugeFloat64("signalfx.accuracy", self).Update(0.5)
+	histogram := metrics.GetOrRegisterHistogram("signalfx.batch_size", self, metrics.NewUniformSample(10))
+	histogram.Update(2)
+	histogram.Update(4)
+	metrics.GetOrRegisterMeter("signalfx.flushes", self).Mark(3)
+
+	h := NewPrometheusHandler()
+	p := newPublisher("", Options{PrometheusHandler: h})
+	p.defaultDims = map[string]string{"host": "a"}
+	h.attach(self, p.defaultDims)
+
+	u := p.prepareUpdate()
+	u.appendIfCounterChanged("http.requests", 7)
+	u.dims, u.dimsKey = map[string]string{"route": "/"}, "[route=/]"
+	u.appendIfGaugeFChanged("http.p99-latency", 0.25)
+	h.record(u)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+
+	// The rate of meters depends on when the meter ticks.
+	body := regexp.MustCompile(`(?m)^(\w+_rate1m) \S+$`).ReplaceAllString(w.Body.String(), "$1 <rate>")
+	c.Assert(body, Equals, `# TYPE http_p99_latency gauge
+http_p99_latency{host="a",route="/"} 0.25
+# TYPE http_requests counter
+http_requests{host="a"} 7
+# TYPE signalfx_accuracy gauge
+signalfx_accuracy 0.5
+# TYPE signalfx_batch_size summary
+signalfx_batch_size{quantile="0.5"} 3
+signalfx_batch_size{quantile="0.99"} 4
+signalfx_batch_size_sum 6
+signalfx_batch_size_count 2
+# TYPE signalfx_bytes_sent counter
+signalfx_bytes_sent 42
+# TYPE signalfx_flushes_total counter
+signalfx_flushes_total 3
+# TYPE signalfx_flushes_rate1m gauge
+signalfx_flushes_rate1m <rate>
+`)
+}
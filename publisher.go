@@ -0,0 +1,152 @@
+package signalfx
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	metrics "github.com/rcrowley/go-metrics"
+)
+
+// Publisher publishes a registry to SignalFX, as PublishToSignalFx does, but
+// with a lifecycle controlled by the caller:
+//
+// 	publisher := signalfx.New(metrics.DefaultRegistry, "<auth_token>")
+// 	publisher.Start()
+// 	defer publisher.Close()
+type Publisher struct {
+	r metrics.Registry
+	p *publisher
+
+	mu     sync.Mutex
+	stop   chan struct{}
+	done   chan struct{}
+	closed bool
+}
+
+// New creates a publisher of the registry, which publishes once started.
+func New(r metrics.Registry, authToken string, options ...Options) *Publisher {
+	opt := singleOptions("New", options)
+	p := newPublisher(authToken, opt)
+	if p.lastSent == nil {
+		p.lastSent = newLastSent()
+	}
+	return &Publisher{r: r, p: p}
+}
+
+// Start starts publishing, in a goroutine. Starting a started or closed
+// publisher does nothing.
+func (pub *Publisher) Start() {
+	pub.mu.Lock()
+	defer pub.mu.Unlock()
+	if pub.stop != nil || pub.closed {
+		return
+	}
+	pub.stop = make(chan struct{})
+	pub.done = make(chan struct{})
+	go func(stop, done chan struct{}) {
+		defer close(done)
+		pub.p.run(pub.r, stop)
+	}(pub.stop, pub.done)
+}
+
+// Stop stops publishing, waiting for the flush in progress, if any, to
+// complete, and then flushes the changes of the last interval, within the
+// ShutdownTimeout. The publisher keeps its state, and can be started again.
+func (pub *Publisher) Stop() {
+	pub.mu.Lock()
+	defer pub.mu.Unlock()
+	pub.stopLocked()
+}
+
+func (pub *Publisher) stopLocked() {
+	if pub.stop == nil {
+		return
+	}
+	close(pub.stop)
+	<-pub.done
+	pub.stop, pub.done = nil, nil
+	pub.finalFlush()
+}
+
+// finalFlush flushes the changes since the last flush, unless publishing
+// is held back, and saves the values sent.
+func (pub *Publisher) finalFlush() {
+	p := pub.p
+	if p.opt.ShutdownTimeout < 0 || p.pauser.isPaused() {
+		return
+	}
+	if warming, _ := p.warmUp.warming(time.Now()); warming {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), p.opt.ShutdownTimeout)
+	defer cancel()
+	p.logf(levelVerbose, "final flush")
+	if err := p.singleContext(ctx, pub.r); err != nil {
+		p.disconnect()
+		p.logPublishError(err)
+		return
+	}
+	p.saveCaches()
+}
+
+// Stats returns the statistics of the publisher since it was created, and
+// of its last flush. It is safe to call from any goroutine, e.g. to report
+// on the publisher itself.
+func (pub *Publisher) Stats() Stats {
+	return pub.p.stats.get()
+}
+
+// CloseOnSignal closes the publisher, with its final flush, when the process
+// receives one of the signals, SIGTERM by default, and then raises the
+// signal again for the process to terminate as it would have. Applications
+// handling the signals themselves should rather call Close as they shut
+// down.
+func (pub *Publisher) CloseOnSignal(signals ...os.Signal) {
+	if len(signals) == 0 {
+		signals = []os.Signal{syscall.SIGTERM}
+	}
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, signals...)
+	go func() {
+		sig := <-c
+		pub.Close()
+		signal.Stop(c)
+		if process, err := os.FindProcess(os.Getpid()); err == nil {
+			process.Signal(sig)
+		}
+	}()
+}
+
+// Pause stops sending, until Resume is called, while the publisher keeps
+// running and its state is kept, e.g. to silence it during a SignalFX
+// maintenance. Publishing resumes with a full flush. The Admin of the
+// publisher, if any, pauses and resumes the same publisher.
+func (pub *Publisher) Pause() {
+	pub.p.pauser.pause()
+}
+
+// Resume resumes sending after Pause, with a full flush.
+func (pub *Publisher) Resume() {
+	pub.p.pauser.resume()
+}
+
+// Paused reports whether sending is paused.
+func (pub *Publisher) Paused() bool {
+	return pub.p.pauser.isPaused()
+}
+
+// Close stops publishing, and releases the connections to SignalFX. A
+// closed publisher cannot be started again.
+func (pub *Publisher) Close() error {
+	pub.mu.Lock()
+	defer pub.mu.Unlock()
+	pub.stopLocked()
+	pub.closed = true
+	pub.p.transport.closeIdleConnections()
+	pub.p.disconnect()
+	return nil
+}
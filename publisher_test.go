@@ -0,0 +1,101 @@
+package signalfx
+
+import (
+	"time"
+
+	metrics "github.com/rcrowley/go-metrics"
+	. "gopkg.in/check.v1"
+)
+
+func (s *Zuite) TestPublisher_lifecycle(c *C) {
+	sender := &fakeSender{}
+	r := metrics.NewRegistry()
+	counter := metrics.GetOrRegisterCounter("requests", r)
+	counter.Inc(1)
+
+	flushed := make(chan FlushStats, 100)
+	publisher := New(r, "", Options{
+		DiffFrequency: time.Millisecond,
+		OnFlush: func(stats FlushStats) {
+			select {
+			case flushed <- stats:
+			default:
+			}
+		},
+	})
+	publisher.p.sender = sender
+
+	publisher.Start()
+	publisher.Start()
+	<-flushed
+	publisher.Stop()
+	publisher.Stop()
+	c.Assert(sender.sent, HasLen, 1)
+
+	// Restarting keeps the state.
+	counter.Inc(1)
+	publisher.Start()
+	for stats := range flushed {
+		if stats.Emitted != 0 {
+			break
+		}
+	}
+	c.Assert(publisher.Close(), IsNil)
+	c.Assert(sender.sent, HasLen, 2)
+	c.Assert(sender.sent[1].Value.String(), Equals, "2")
+
+	// Closed publishers cannot be restarted.
+	publisher.Start()
+	c.Assert(publisher.stop, IsNil)
+}
+
+func (s *Zuite) TestPublisher_pause(c *C) {
+	admin := NewAdmin()
+	publisher := New(metrics.NewRegistry(), "", Options{Admin: admin})
+
+	publisher.Pause()
+	c.Assert(publisher.Paused(), Equals, true)
+	c.Assert(admin.Paused(), Equals, true)
+	flush, _, _ := publisher.p.pauser.flushing()
+	c.Assert(flush, Equals, false)
+
+	publisher.Resume()
+	c.Assert(publisher.Paused(), Equals, false)
+	flush, _, resumed := publisher.p.pauser.flushing()
+	c.Assert(flush, Equals, true)
+	c.Assert(resumed, Equals, true)
+}
+
+func (s *Zuite) TestPublisher_finalFlush(c *C) {
+	sender := &fakeSender{}
+	r := metrics.NewRegistry()
+	metrics.GetOrRegisterCounter("requests", r).Inc(1)
+
+	publisher := New(r, "", Options{DiffFrequency: time.Hour})
+	publisher.p.sender = sender
+	publisher.Start()
+	publisher.Stop()
+	c.Assert(sender.sent, HasLen, 1)
+
+	// Without changes, nothing is sent.
+	publisher.Start()
+	c.Assert(publisher.Close(), IsNil)
+	c.Assert(sender.sent, HasLen, 1)
+}
+
+func (s *Zuite) TestPublisher_finalFlushDisabled(c *C) {
+	sender := &fakeSender{}
+	r := metrics.NewRegistry()
+	metrics.GetOrRegisterCounter("requests", r).Inc(1)
+
+	for _, opt := range []Options{
+		{DiffFrequency: time.Hour, ShutdownTimeout: -1},
+		{DiffFrequency: time.Hour, WaitForReady: true},
+	} {
+		publisher := New(r, "", opt)
+		publisher.p.sender = sender
+		publisher.Start()
+		c.Assert(publisher.Close(), IsNil)
+	}
+	c.Assert(sender.sent, HasLen, 0)
+}
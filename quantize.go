@@ -0,0 +1,19 @@
+package signalfx
+
+import (
+	"math"
+	"strconv"
+)
+
+// quantize rounds the value to the number of significant digits, unless
+// digits is not positive.
+func quantize(value float64, digits int) float64 {
+	if digits <= 0 || value == 0 || math.IsNaN(value) || math.IsInf(value, 0) {
+		return value
+	}
+	rounded, err := strconv.ParseFloat(strconv.FormatFloat(value, 'g', digits, 64), 64)
+	if err != nil {
+		return value
+	}
+	return rounded
+}
@@ -0,0 +1,41 @@
+package signalfx
+
+import (
+	"math"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *Zuite) TestQuantize(c *C) {
+	for _, example := range []struct {
+		value    float64
+		digits   int
+		expected float64
+	}{
+		{0.1 + 0.2, 0, 0.1 + 0.2},
+		{0.1 + 0.2, 6, 0.3},
+		{12345.678, 3, 12300},
+		{-0.00123456, 2, -0.0012},
+		{1.0000000000001, 12, 1},
+		{0, 3, 0},
+	} {
+		c.Check(quantize(example.value, example.digits), Equals, example.expected,
+			Commentf("value=%v digits=%d", example.value, example.digits))
+	}
+	c.Assert(math.IsInf(quantize(math.Inf(1), 3), 1), Equals, true)
+	c.Assert(math.IsNaN(quantize(math.NaN(), 3)), Equals, true)
+}
+
+func (s *Zuite) TestAppendIfGaugeFChanged_significantDigits(c *C) {
+	p := newPublisher("", Options{SignificantDigits: 4})
+	p.last.gauges_f["rate"] = 1.235
+
+	u := p.prepareUpdate()
+	u.appendIfGaugeFChanged("rate", 1.23456789)
+	c.Assert(u.ds, HasLen, 0)
+
+	u = p.prepareUpdate()
+	u.appendIfGaugeFChanged("rate", 1.2367)
+	c.Assert(u.ds, HasLen, 1)
+	c.Assert(u.ds[0].Value.String(), Equals, "1.237")
+}
@@ -0,0 +1,59 @@
+package signalfx
+
+import (
+	"sort"
+	"sync"
+)
+
+// quarantine keeps the series whose datapoints SignalFX rejected, which are
+// not sent anymore until the quarantine is cleared.
+type quarantine struct {
+	mu     sync.Mutex
+	series map[string]string
+}
+
+func newQuarantine() *quarantine {
+	return &quarantine{series: make(map[string]string)}
+}
+
+// add quarantines the series, for the given reason.
+func (q *quarantine) add(key, reason string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.series[key] = reason
+}
+
+func (q *quarantine) contains(key string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	_, ok := q.series[key]
+	return ok
+}
+
+// list returns the quarantined series, with the reason of their quarantine.
+func (q *quarantine) list() map[string]string {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	series := make(map[string]string, len(q.series))
+	for key, reason := range q.series {
+		series[key] = reason
+	}
+	return series
+}
+
+func (q *quarantine) clear() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.series = make(map[string]string)
+}
+
+// keys returns the quarantined series, sorted.
+func (q *quarantine) keys() []string {
+	series := q.list()
+	keys := make([]string, 0, len(series))
+	for key := range series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
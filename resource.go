@@ -0,0 +1,200 @@
+package signalfx
+
+import (
+	"bufio"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// ResourceDetector detects attributes describing the resource producing the
+// metrics, such as the host, the cloud region, or the container. Detectors
+// are executed once, when the publisher starts, and the attributes they
+// detect are attached as dimensions to all datapoints.
+type ResourceDetector interface {
+	// Detect returns the detected attributes, keyed by OpenTelemetry
+	// attribute names such as "host.name". Detectors which do not apply to
+	// the current environment return no attributes.
+	Detect() (map[string]string, error)
+}
+
+// ResourceDetectorFunc adapts a function to a ResourceDetector.
+type ResourceDetectorFunc func() (map[string]string, error)
+
+// Detect calls f.
+func (f ResourceDetectorFunc) Detect() (map[string]string, error) {
+	return f()
+}
+
+// detectResource runs the detectors in order, attributes of later detectors
+// overriding those of earlier ones, and converts the attributes into
+// dimensions. Failing detectors are reported through the logger and skipped.
+func detectResource(detectors []ResourceDetector, logf func(format string, v ...interface{})) map[string]string {
+	var dims map[string]string
+	for _, detector := range detectors {
+		attributes, err := detector.Detect()
+		if err != nil {
+			logf("resource detection failed: %s", err)
+			continue
+		}
+		for key, value := range attributes {
+			if key != "" && value != "" {
+				dims = mergeDimensions(dims, map[string]string{dimensionKey(key): value})
+			}
+		}
+	}
+	return dims
+}
+
+// ServiceDetector detects the service name, deployment environment and
+// service version from SERVICE_NAME, DEPLOYMENT_ENVIRONMENT and
+// SERVICE_VERSION, or from OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES.
+func ServiceDetector() ResourceDetector {
+	return ResourceDetectorFunc(func() (map[string]string, error) {
+		return serviceDimensionsFromEnv(os.Getenv), nil
+	})
+}
+
+// HostDetector detects the host name.
+func HostDetector() ResourceDetector {
+	return ResourceDetectorFunc(func() (map[string]string, error) {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return nil, err
+		}
+		return map[string]string{"host.name": hostname}, nil
+	})
+}
+
+// CloudDetector detects the cloud provider and region from the environment
+// variables set by AWS and Google Cloud runtimes.
+func CloudDetector() ResourceDetector {
+	return ResourceDetectorFunc(func() (map[string]string, error) {
+		return cloudAttributesFromEnv(os.Getenv), nil
+	})
+}
+
+// ContainerDetector detects the identifier of the container the process runs
+// in, from its cgroup.
+func ContainerDetector() ResourceDetector {
+	return ResourceDetectorFunc(func() (map[string]string, error) {
+		f, err := os.Open("/proc/self/cgroup")
+		if os.IsNotExist(err) {
+			return nil, nil
+		} else if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			if id := containerIDFromCgroup(scanner.Text()); id != "" {
+				return map[string]string{"container.id": id}, nil
+			}
+		}
+		return nil, scanner.Err()
+	})
+}
+
+// KubernetesDetector detects the pod, namespace and node when running in
+// Kubernetes. The node name is read from the NODE_NAME variable, and the
+// namespace from POD_NAMESPACE when set, as commonly exposed through the
+// downward API.
+func KubernetesDetector() ResourceDetector {
+	return ResourceDetectorFunc(func() (map[string]string, error) {
+		namespace, err := ioutil.ReadFile("/var/run/secrets/kubernetes.io/serviceaccount/namespace")
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		return kubernetesAttributesFromEnv(os.Getenv, string(namespace)), nil
+	})
+}
+
+// DefaultResourceDetectors returns the built-in detectors, in the order in
+// which they should run.
+func DefaultResourceDetectors() []ResourceDetector {
+	return []ResourceDetector{
+		HostDetector(),
+		CloudDetector(),
+		ContainerDetector(),
+		KubernetesDetector(),
+		ServiceDetector(),
+	}
+}
+
+func cloudAttributesFromEnv(getenv func(string) string) map[string]string {
+	if region := firstNonEmpty(getenv("AWS_REGION"), getenv("AWS_DEFAULT_REGION")); region != "" {
+		return map[string]string{"cloud.provider": "aws", "cloud.region": region}
+	}
+	if project := firstNonEmpty(getenv("GOOGLE_CLOUD_PROJECT"), getenv("GCLOUD_PROJECT")); project != "" {
+		return map[string]string{"cloud.provider": "gcp", "cloud.account.id": project}
+	}
+	return nil
+}
+
+func kubernetesAttributesFromEnv(getenv func(string) string, namespace string) map[string]string {
+	if getenv("KUBERNETES_SERVICE_HOST") == "" {
+		return nil
+	}
+	return map[string]string{
+		"k8s.pod.name":       firstNonEmpty(getenv("POD_NAME"), getenv("HOSTNAME")),
+		"k8s.namespace.name": firstNonEmpty(getenv("POD_NAMESPACE"), strings.TrimSpace(namespace)),
+		"k8s.node.name":      getenv("NODE_NAME"),
+	}
+}
+
+var containerIDPattern = regexp.MustCompile(`([0-9a-f]{64})(?:\.scope)?$`)
+
+// containerIDFromCgroup extracts the container identifier from a line of
+// /proc/self/cgroup, e.g. "12:pids:/docker/<id>".
+func containerIDFromCgroup(line string) string {
+	if m := containerIDPattern.FindStringSubmatch(line); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// serviceEnvironmentVariables maps the environment variables describing the
+// service to the resource attribute they set, in increasing precedence.
+var serviceEnvironmentVariables = []struct{ env, attribute string }{
+	{"OTEL_SERVICE_NAME", "service.name"},
+	{"SERVICE_NAME", "service.name"},
+	{"DEPLOYMENT_ENVIRONMENT", "deployment.environment"},
+	{"SERVICE_VERSION", "service.version"},
+}
+
+// serviceDimensionsFromEnv reads the standard service description from the
+// environment, following the OpenTelemetry resource conventions. Attributes
+// from OTEL_RESOURCE_ATTRIBUTES are overridden by the dedicated variables
+// such as SERVICE_NAME. Since SignalFX dimension keys cannot contain dots,
+// attribute keys such as "service.name" become "service_name".
+func serviceDimensionsFromEnv(getenv func(string) string) map[string]string {
+	dims := make(map[string]string)
+	for _, pair := range strings.Split(getenv("OTEL_RESOURCE_ATTRIBUTES"), ",") {
+		if kv := strings.SplitN(pair, "=", 2); len(kv) == 2 {
+			if key, value := strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1]); key != "" && value != "" {
+				dims[dimensionKey(key)] = value
+			}
+		}
+	}
+	for _, v := range serviceEnvironmentVariables {
+		if value := getenv(v.env); value != "" {
+			dims[dimensionKey(v.attribute)] = value
+		}
+	}
+	return dims
+}
+
+// dimensionKey converts an attribute key into a valid SignalFX dimension key.
+func dimensionKey(key string) string {
+	return strings.Replace(key, ".", "_", -1)
+}
@@ -0,0 +1,89 @@
+package signalfx
+
+import (
+	"errors"
+	"fmt"
+
+	metrics "github.com/rcrowley/go-metrics"
+	. "gopkg.in/check.v1"
+)
+
+func (s *Zuite) TestServiceDimensionsFromEnv(c *C) {
+	env := map[string]string{
+		"OTEL_RESOURCE_ATTRIBUTES": "service.name=otel, deployment.environment=staging,team=payments,broken",
+		"SERVICE_NAME":             "checkout",
+		"SERVICE_VERSION":          "1.2.3",
+	}
+	getenv := func(key string) string { return env[key] }
+
+	c.Assert(serviceDimensionsFromEnv(getenv), DeepEquals, map[string]string{
+		"service_name":           "checkout",
+		"deployment_environment": "staging",
+		"service_version":        "1.2.3",
+		"team":                   "payments",
+	})
+}
+
+func (s *Zuite) TestServiceDimensionsFromEnv_empty(c *C) {
+	getenv := func(string) string { return "" }
+
+	c.Assert(serviceDimensionsFromEnv(getenv), HasLen, 0)
+}
+
+func (s *Zuite) TestMetricToDatapoints_defaultDimensions(c *C) {
+	p := newPublisher("", Options{})
+	p.defaultDims = map[string]string{"service_name": "checkout", "env": "prod"}
+
+	u := p.prepareUpdate()
+	u.metricToDatapoints(NameWithDimensions("orders", map[string]string{"env": "dev"}), metrics.NewGauge())
+
+	c.Assert(u.ds, HasLen, 1)
+	c.Assert(u.ds[0].Dimensions, DeepEquals, map[string]string{"service_name": "checkout", "env": "dev"})
+	c.Assert(u.changes.gauges["orders[env=dev]"], Equals, int64(0))
+}
+
+func (s *Zuite) TestDetectResource(c *C) {
+	var logged []string
+	logf := func(format string, v ...interface{}) {
+		logged = append(logged, fmt.Sprintf(format, v...))
+	}
+
+	dims := detectResource([]ResourceDetector{
+		ResourceDetectorFunc(func() (map[string]string, error) {
+			return map[string]string{"host.name": "a", "team": "payments"}, nil
+		}),
+		ResourceDetectorFunc(func() (map[string]string, error) {
+			return nil, errors.New("boom")
+		}),
+		ResourceDetectorFunc(func() (map[string]string, error) {
+			return map[string]string{"host.name": "b", "empty": ""}, nil
+		}),
+	}, logf)
+
+	c.Assert(dims, DeepEquals, map[string]string{"host_name": "b", "team": "payments"})
+	c.Assert(logged, DeepEquals, []string{"resource detection failed: boom"})
+}
+
+func (s *Zuite) TestContainerIDFromCgroup(c *C) {
+	id := "3f4b1c8a9e2d7f6b5a4c3d2e1f0a9b8c7d6e5f4a3b2c1d0e9f8a7b6c5d4e3f2a"
+
+	c.Assert(containerIDFromCgroup("12:pids:/docker/"+id), Equals, id)
+	c.Assert(containerIDFromCgroup("0::/system.slice/docker-"+id+".scope"), Equals, id)
+	c.Assert(containerIDFromCgroup("0::/user.slice"), Equals, "")
+}
+
+func (s *Zuite) TestKubernetesAttributesFromEnv(c *C) {
+	env := map[string]string{
+		"KUBERNETES_SERVICE_HOST": "10.0.0.1",
+		"HOSTNAME":                "checkout-7d9f",
+		"NODE_NAME":               "node-1",
+	}
+	getenv := func(key string) string { return env[key] }
+
+	c.Assert(kubernetesAttributesFromEnv(getenv, "payments\n"), DeepEquals, map[string]string{
+		"k8s.pod.name":       "checkout-7d9f",
+		"k8s.namespace.name": "payments",
+		"k8s.node.name":      "node-1",
+	})
+	c.Assert(kubernetesAttributesFromEnv(func(string) string { return "" }, ""), IsNil)
+}
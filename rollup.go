@@ -0,0 +1,122 @@
+package signalfx
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"sync"
+)
+
+// RollupAction is what is done to dimensions rolled up, see Rollup.
+type RollupAction int
+
+const (
+	// RollupHash replaces the values of the dimension by the bucket their
+	// hash falls in.
+	RollupHash RollupAction = iota
+
+	// RollupDrop removes the dimension.
+	RollupDrop
+)
+
+// Rollup is the policy for dimensions whose number of distinct values grows
+// without bound, such as UUIDs or user IDs. Once a dimension has more than
+// Threshold values it is rolled up: its values are hashed into Buckets
+// buckets, or it is dropped, depending on the Action. Dimensions capped by
+// MaxDimensionValues are not rolled up.
+type Rollup struct {
+	Threshold int
+	Action    RollupAction
+	Buckets   int
+}
+
+// defaultRollupBuckets is the number of buckets values are hashed into when
+// unspecified.
+const defaultRollupBuckets = 16
+
+// rollupTracker detects the dimensions to roll up.
+type rollupTracker struct {
+	policy Rollup
+	skip   map[string]int
+	values map[string]map[string]struct{}
+
+	// mu guards rolled, which is read by the Admin.
+	mu     sync.Mutex
+	rolled map[string]struct{}
+}
+
+func newRollupTracker(policy Rollup, skip map[string]int) *rollupTracker {
+	if policy.Buckets <= 0 {
+		policy.Buckets = defaultRollupBuckets
+	}
+	return &rollupTracker{
+		policy: policy,
+		skip:   skip,
+		values: make(map[string]map[string]struct{}),
+		rolled: make(map[string]struct{}),
+	}
+}
+
+// rollup returns the dimensions with the dimensions rolled up hashed or
+// dropped, the number of dimensions modified, and the keys which were rolled
+// up for the first time. The dimensions are copied if modified.
+func (t *rollupTracker) rollup(dims map[string]string) (rolled map[string]string, modified int, keys []string) {
+	rolled = dims
+	for key, value := range dims {
+		if _, ok := t.skip[key]; ok {
+			continue
+		}
+		if !t.isRolled(key) {
+			values := t.values[key]
+			if values == nil {
+				values = make(map[string]struct{})
+				t.values[key] = values
+			}
+			values[value] = struct{}{}
+			if len(values) <= t.policy.Threshold {
+				continue
+			}
+			t.mu.Lock()
+			t.rolled[key] = struct{}{}
+			t.mu.Unlock()
+			delete(t.values, key)
+			keys = append(keys, key)
+		}
+		if modified == 0 {
+			rolled = mergeDimensions(dims)
+		}
+		modified++
+		if t.policy.Action == RollupDrop {
+			delete(rolled, key)
+		} else {
+			rolled[key] = t.bucket(value)
+		}
+	}
+	return rolled, modified, keys
+}
+
+func (t *rollupTracker) isRolled(key string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	_, ok := t.rolled[key]
+	return ok
+}
+
+// bucket returns the bucket the hash of the value falls in.
+func (t *rollupTracker) bucket(value string) string {
+	h := fnv.New32a()
+	h.Write([]byte(value))
+	return fmt.Sprintf("bucket-%d", h.Sum32()%uint32(t.policy.Buckets))
+}
+
+// keys returns the dimensions rolled up, sorted.
+func (t *rollupTracker) keys() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	keys := make([]string, 0, len(t.rolled))
+	for key := range t.rolled {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,47 @@
+package signalfx
+
+import (
+	metrics "github.com/rcrowley/go-metrics"
+	. "gopkg.in/check.v1"
+)
+
+func (s *Zuite) TestRollupTracker_hash(c *C) {
+	t := newRollupTracker(Rollup{Threshold: 2, Buckets: 4}, map[string]int{"endpoint": 10})
+
+	for _, user := range []string{"a", "b", "a"} {
+		dims := map[string]string{"user": user, "endpoint": "/" + user}
+		rolled, modified, keys := t.rollup(dims)
+		c.Assert(rolled, DeepEquals, dims)
+		c.Assert(modified, Equals, 0)
+		c.Assert(keys, HasLen, 0)
+	}
+
+	rolled, modified, keys := t.rollup(map[string]string{"user": "c", "endpoint": "/c"})
+	c.Assert(rolled, DeepEquals, map[string]string{"user": t.bucket("c"), "endpoint": "/c"})
+	c.Assert(modified, Equals, 1)
+	c.Assert(keys, DeepEquals, []string{"user"})
+
+	rolled, _, keys = t.rollup(map[string]string{"user": "a"})
+	c.Assert(rolled, DeepEquals, map[string]string{"user": t.bucket("a")})
+	c.Assert(keys, HasLen, 0)
+	c.Assert(t.keys(), DeepEquals, []string{"user"})
+	c.Assert(t.bucket("a"), Matches, "bucket-[0-3]")
+}
+
+func (s *Zuite) TestMetricToDatapoints_rollupDrop(c *C) {
+	logger := &recordingLogger{}
+	p := newPublisher("", Options{Logger: logger, Rollup: &Rollup{Threshold: 1, Action: RollupDrop}})
+	u := p.prepareUpdate()
+
+	for _, id := range []string{"1", "2", "3"} {
+		u.source = origin{name: id}
+		u.metricToDatapoints(NameWithDimensions("orders", map[string]string{"order": id, "env": "prod"}), metrics.NewCounter())
+	}
+
+	c.Assert(u.ds, HasLen, 2)
+	c.Assert(u.ds[1].Dimensions, DeepEquals, map[string]string{"env": "prod"})
+	c.Assert(u.stats.RolledUp, Equals, 2)
+	c.Assert(logger.lines, DeepEquals, []string{
+		`WARNING: dimension "order" has more than 1 values, rolling it up (rolled up dimensions: [order])`,
+	})
+}
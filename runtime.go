@@ -0,0 +1,162 @@
+package signalfx
+
+import (
+	"math"
+	runtimemetrics "runtime/metrics"
+	"sync"
+	"time"
+
+	metrics "github.com/rcrowley/go-metrics"
+)
+
+// maxRuntimeSamples bounds the number of samples added to a runtime timer per
+// capture. The runtime can observe millions of scheduling events in between
+// captures, and timers only keep a sample of their updates anyway.
+const maxRuntimeSamples = 1028
+
+// runtimeTimers are the timers of runtime latency distributions, by the name
+// of the runtime/metrics histogram they are captured from. The GC pauses
+// histogram was renamed in Go 1.22, both names are captured from.
+var runtimeTimers = []struct {
+	name    string
+	sources []string
+}{
+	{"runtime.gc.pause", []string{"/sched/pauses/total/gc:seconds", "/gc/pauses:seconds"}},
+	{"runtime.sched.latency", []string{"/sched/latencies:seconds"}},
+}
+
+// runtimeCapture keeps the counts of the runtime histograms last captured,
+// by timer, so that timers of different registries each get all the events.
+var runtimeCapture struct {
+	sync.Mutex
+	last map[metrics.Timer][]uint64
+}
+
+// RegisterRuntimeTimers registers timers of the distributions of GC pause
+// durations, runtime.gc.pause, and of the time goroutines wait to be
+// scheduled, runtime.sched.latency. They are updated by CaptureRuntimeTimers.
+//
+// As the runtime can observe millions of events between captures, each
+// capture updates the timers with at most 1028 of them, a proportional
+// sample: the count of the timers is the number of events sampled, not the
+// number of events observed, while their distribution is accurate.
+func RegisterRuntimeTimers(r metrics.Registry) {
+	for _, t := range runtimeTimers {
+		r.Register(t.name, metrics.NewTimer())
+	}
+}
+
+// CaptureRuntimeTimers updates the runtime timers registered by
+// RegisterRuntimeTimers every d, and never returns:
+//
+// 	signalfx.RegisterRuntimeTimers(metrics.DefaultRegistry)
+// 	go signalfx.CaptureRuntimeTimers(metrics.DefaultRegistry, 5*time.Second)
+func CaptureRuntimeTimers(r metrics.Registry, d time.Duration) {
+	for range time.Tick(d) {
+		CaptureRuntimeTimersOnce(r)
+	}
+}
+
+// CaptureRuntimeTimersOnce updates the runtime timers registered by
+// RegisterRuntimeTimers once, with the events the runtime observed since the
+// previous capture. When there are many events, the timers are updated with
+// a proportional sample of them.
+func CaptureRuntimeTimersOnce(r metrics.Registry) {
+	samples := runtimeSamples()
+	runtimemetrics.Read(samples)
+
+	runtimeCapture.Lock()
+	defer runtimeCapture.Unlock()
+	if runtimeCapture.last == nil {
+		runtimeCapture.last = make(map[metrics.Timer][]uint64)
+	}
+	for i, t := range runtimeTimers {
+		timer, ok := r.Get(t.name).(metrics.Timer)
+		if !ok || samples[i].Value.Kind() != runtimemetrics.KindFloat64Histogram {
+			continue
+		}
+		h := samples[i].Value.Float64Histogram()
+		last := runtimeCapture.last[timer]
+		runtimeCapture.last[timer] = append([]uint64(nil), h.Counts...)
+		if len(last) != len(h.Counts) {
+			// First capture, the distribution since the process started is
+			// not attributed to the first interval.
+			continue
+		}
+		updateFromHistogram(timer, h, last)
+	}
+}
+
+// newRuntimeRegistry creates the registry of the RuntimeMetrics, warning
+// when go-metrics registered its runtime metrics in another registry first,
+// as it only registers them once per process.
+func newRuntimeRegistry(logf func(format string, v ...interface{})) metrics.Registry {
+	r := metrics.NewRegistry()
+	metrics.RegisterRuntimeMemStats(r)
+	if r.Get("runtime.MemStats.Alloc") == nil {
+		logf("WARNING: the runtime metrics of go-metrics are registered in another registry, publishing the runtime timers only")
+	}
+	RegisterRuntimeTimers(r)
+	return r
+}
+
+// captureRuntimeMetrics captures the RuntimeMetrics in their registry.
+func (p *publisher) captureRuntimeMetrics() {
+	metrics.CaptureRuntimeMemStatsOnce(p.runtime)
+	CaptureRuntimeTimersOnce(p.runtime)
+}
+
+// runtimeSamples returns the samples to read for the runtime timers, in the
+// order of runtimeTimers, using the first source supported by the runtime.
+func runtimeSamples() []runtimemetrics.Sample {
+	supported := make(map[string]bool)
+	for _, d := range runtimemetrics.All() {
+		supported[d.Name] = true
+	}
+	samples := make([]runtimemetrics.Sample, len(runtimeTimers))
+	for i, t := range runtimeTimers {
+		samples[i].Name = t.sources[len(t.sources)-1]
+		for _, source := range t.sources {
+			if supported[source] {
+				samples[i].Name = source
+				break
+			}
+		}
+	}
+	return samples
+}
+
+// updateFromHistogram updates the timer with the events counted in the
+// histogram since the last counts, each recorded as the middle of its bucket.
+func updateFromHistogram(timer metrics.Timer, h *runtimemetrics.Float64Histogram, last []uint64) {
+	var total uint64
+	for i, count := range h.Counts {
+		total += count - last[i]
+	}
+	ratio := 1.0
+	if total > maxRuntimeSamples {
+		ratio = float64(maxRuntimeSamples) / float64(total)
+	}
+	for i, count := range h.Counts {
+		n := int(math.Round(float64(count-last[i]) * ratio))
+		if n == 0 {
+			continue
+		}
+		d := time.Duration(bucketValue(h.Buckets[i], h.Buckets[i+1]) * float64(time.Second))
+		for ; n > 0; n-- {
+			timer.Update(d)
+		}
+	}
+}
+
+// bucketValue returns the value representing a bucket of a histogram: its
+// middle, or its finite bound when the other is infinite.
+func bucketValue(lower, upper float64) float64 {
+	switch {
+	case math.IsInf(lower, -1):
+		return upper
+	case math.IsInf(upper, 1):
+		return lower
+	}
+	return (lower + upper) / 2
+}
@@ -0,0 +1,91 @@
+package signalfx
+
+import (
+	"math"
+	"runtime"
+	runtimemetrics "runtime/metrics"
+	"time"
+
+	metrics "github.com/rcrowley/go-metrics"
+	. "gopkg.in/check.v1"
+)
+
+func (s *Zuite) TestRuntimeTimers(c *C) {
+	r := metrics.NewRegistry()
+	RegisterRuntimeTimers(r)
+	CaptureRuntimeTimersOnce(r)
+	runtime.GC()
+	CaptureRuntimeTimersOnce(r)
+
+	c.Assert(r.Get("runtime.gc.pause").(metrics.Timer).Count(), Not(Equals), int64(0))
+	c.Assert(r.Get("runtime.sched.latency"), FitsTypeOf, metrics.NewTimer())
+}
+
+func (s *Zuite) TestRuntimeTimers_registries(c *C) {
+	r1, r2 := metrics.NewRegistry(), metrics.NewRegistry()
+	RegisterRuntimeTimers(r1)
+	RegisterRuntimeTimers(r2)
+	CaptureRuntimeTimersOnce(r1)
+	CaptureRuntimeTimersOnce(r2)
+	runtime.GC()
+	CaptureRuntimeTimersOnce(r1)
+	CaptureRuntimeTimersOnce(r2)
+
+	// Each registry gets the events since its own previous capture.
+	c.Assert(r1.Get("runtime.gc.pause").(metrics.Timer).Count(), Not(Equals), int64(0))
+	c.Assert(r2.Get("runtime.gc.pause").(metrics.Timer).Count(), Not(Equals), int64(0))
+}
+
+func (s *Zuite) TestUpdateFromHistogram(c *C) {
+	h := &runtimemetrics.Float64Histogram{
+		Counts:  []uint64{1, 3, 10},
+		Buckets: []float64{math.Inf(-1), 0.001, 0.003, math.Inf(1)},
+	}
+	timer := metrics.NewTimer()
+	updateFromHistogram(timer, h, []uint64{0, 1, 10})
+
+	c.Assert(timer.Count(), Equals, int64(3))
+	c.Assert(timer.Min(), Equals, int64(time.Millisecond))
+	c.Assert(timer.Max(), Equals, int64(2*time.Millisecond))
+}
+
+func (s *Zuite) TestUpdateFromHistogram_samples(c *C) {
+	h := &runtimemetrics.Float64Histogram{
+		Counts:  []uint64{10 * maxRuntimeSamples, 10 * maxRuntimeSamples},
+		Buckets: []float64{0, 0.001, 0.002},
+	}
+	timer := metrics.NewTimer()
+	updateFromHistogram(timer, h, []uint64{0, 0})
+
+	c.Assert(timer.Count(), Equals, int64(maxRuntimeSamples))
+}
+
+func (s *Zuite) TestRuntimeMetrics(c *C) {
+	p, sender := newTestPublisher(Options{RuntimeMetrics: true})
+	r := metrics.NewRegistry()
+
+	c.Assert(p.runtime.Get("runtime.sched.latency"), FitsTypeOf, metrics.NewTimer())
+	c.Assert(p.single(r), IsNil)
+
+	// The published registry is left untouched.
+	c.Assert(r.Get("runtime.sched.latency"), IsNil)
+
+	// The metrics are captured again before each flush.
+	runtime.GC()
+	sender.sent = nil
+	c.Assert(p.single(r), IsNil)
+	point, ok := newBatch(sender.sent).Get("runtime.gc.pause.count", nil)
+	c.Assert(ok, Equals, true)
+	c.Assert(point.Value > 0, Equals, true)
+}
+
+func (s *Zuite) TestRuntimeMetrics_registeredElsewhere(c *C) {
+	// go-metrics registers its runtime metrics in the first registry only.
+	newPublisher("", Options{RuntimeMetrics: true})
+	logger := &recordingLogger{}
+	p := newPublisher("", Options{RuntimeMetrics: true, Logger: logger})
+	c.Assert(logger.lines, DeepEquals, []string{
+		"WARNING: the runtime metrics of go-metrics are registered in another registry, publishing the runtime timers only",
+	})
+	c.Assert(p.runtime.Get("runtime.gc.pause"), FitsTypeOf, metrics.NewTimer())
+}
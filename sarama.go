@@ -0,0 +1,49 @@
+package signalfx
+
+import (
+	"regexp"
+
+	metrics "github.com/rcrowley/go-metrics"
+)
+
+// saramaPatterns extract the dimensions Sarama encodes in the names of its
+// metrics, e.g. request-rate-for-broker-1. The first submatch is the name of
+// the metric, the second the value of the dimension.
+var saramaPatterns = []struct {
+	pattern   *regexp.Regexp
+	dimension string
+}{
+	{regexp.MustCompile(`^(.+?)-for-broker-(-?\d+)$`), "broker"},
+	{regexp.MustCompile(`^(.+?)-for-topic-(.+)$`), "topic"},
+	{regexp.MustCompile(`^(consumer-group-(?:join|sync)-(?:total|failed))-(.+)$`), "group"},
+}
+
+// Sarama returns a view of the registry of a Sarama client, its
+// Config.MetricRegistry, in which the metrics are published under kafka.,
+// with the broker, topic, and consumer group encoded in their names as
+// dimensions. For instance, request-rate-for-broker-1 is published as
+// kafka.request-rate with dimension broker=1:
+//
+// 	go signalfx.PublishToSignalFx(metrics.DefaultRegistry, "<auth_token>", signalfx.Options{
+// 		Registries: []metrics.Registry{signalfx.Sarama(config.MetricRegistry)},
+// 	})
+//
+// Only the names of iterated metrics are converted, metrics are looked up
+// and registered through the view with the names Sarama uses.
+func Sarama(r metrics.Registry) metrics.Registry {
+	return &View{
+		Registry: r,
+		Rename:   saramaName,
+		Scope:    func(name string) string { return name },
+	}
+}
+
+// saramaName converts the name of a Sarama metric.
+func saramaName(name string) string {
+	for _, p := range saramaPatterns {
+		if m := p.pattern.FindStringSubmatch(name); m != nil {
+			return NameWithDimensions("kafka."+m[1], map[string]string{p.dimension: m[2]})
+		}
+	}
+	return "kafka." + name
+}
@@ -0,0 +1,31 @@
+package signalfx
+
+import (
+	metrics "github.com/rcrowley/go-metrics"
+	. "gopkg.in/check.v1"
+)
+
+func (s *Zuite) TestSaramaName(c *C) {
+	for name, expected := range map[string]string{
+		"request-rate":                       "kafka.request-rate",
+		"request-rate-for-broker-1":          "kafka.request-rate[broker=1]",
+		"record-send-rate-for-topic-orders":  "kafka.record-send-rate[topic=orders]",
+		"batch-size-for-topic-my-topic":      "kafka.batch-size[topic=my-topic]",
+		"consumer-group-join-total-billing":  "kafka.consumer-group-join-total[group=billing]",
+		"consumer-group-sync-failed-billing": "kafka.consumer-group-sync-failed[group=billing]",
+	} {
+		c.Assert(saramaName(name), Equals, expected)
+	}
+}
+
+func (s *Zuite) TestSarama(c *C) {
+	r := metrics.NewRegistry()
+	metrics.GetOrRegisterMeter("incoming-byte-rate-for-broker-2", r)
+
+	var names []string
+	Sarama(r).Each(func(name string, _ interface{}) {
+		names = append(names, name)
+	})
+	c.Assert(names, DeepEquals, []string{"kafka.incoming-byte-rate[broker=2]"})
+	c.Assert(Sarama(r).Get("incoming-byte-rate-for-broker-2"), NotNil)
+}
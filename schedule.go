@@ -0,0 +1,146 @@
+package signalfx
+
+import (
+	"sync"
+	"time"
+)
+
+// scheduleTraceSize is the number of ticks kept by the schedule trace.
+const scheduleTraceSize = 100
+
+// Tick describes a tick of the publisher, to tell whether a gap in charts
+// was caused by scheduling delays, by a failed flush, or by suppression.
+type Tick struct {
+	// Scheduled is the time the tick was due, Started the time it was
+	// handled, and Duration how long handling it took.
+	Scheduled time.Time     `json:"scheduled"`
+	Started   time.Time     `json:"started"`
+	Duration  time.Duration `json:"duration"`
+
+	// Missed is the number of ticks dropped since the previous one, because
+	// the publisher was busy, and Overran is set when handling the tick
+	// took longer than Options.DiffFrequency.
+	Missed  int  `json:"missed,omitempty"`
+	Overran bool `json:"overran,omitempty"`
+
+	// Skipped is the reason the tick did not flush, if it did not, e.g.
+	// "paused" or "circuit open".
+	Skipped string `json:"skipped,omitempty"`
+
+	// Emitted and Suppressed are those of the flush, see FlushStats, and
+	// Err its error, if any.
+	Emitted    int    `json:"emitted"`
+	Suppressed int    `json:"suppressed"`
+	Err        string `json:"error,omitempty"`
+}
+
+// scheduleTrace keeps the recent ticks of the publisher, in a ring buffer.
+type scheduleTrace struct {
+	mu    sync.Mutex
+	ticks []Tick
+	next  int
+
+	// current is the tick being handled, if any, interval the frequency it
+	// was scheduled at, and last the time the previous tick was due.
+	current  *Tick
+	interval time.Duration
+	last     time.Time
+}
+
+func newScheduleTrace() *scheduleTrace {
+	return &scheduleTrace{ticks: make([]Tick, 0, scheduleTraceSize)}
+}
+
+// begin starts tracing the tick due at the time, scheduled every interval.
+func (t *scheduleTrace) begin(scheduled time.Time, interval time.Duration) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	tick := &Tick{Scheduled: scheduled, Started: time.Now()}
+	if !t.last.IsZero() && interval > 0 {
+		if missed := int((scheduled.Sub(t.last)+interval/2)/interval) - 1; missed > 0 {
+			tick.Missed = missed
+		}
+	}
+	t.current = tick
+	t.interval = interval
+	t.last = scheduled
+}
+
+// flushed records the stats of the flush of the current tick.
+func (t *scheduleTrace) flushed(stats FlushStats) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.current == nil {
+		return
+	}
+	t.current.Emitted = stats.Emitted
+	t.current.Suppressed = stats.Suppressed
+	if stats.Err != nil {
+		t.current.Err = stats.Err.Error()
+	}
+}
+
+// skip ends the current tick, which did not flush for the reason.
+func (t *scheduleTrace) skip(reason string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.current == nil {
+		return
+	}
+	t.current.Skipped = reason
+	t.finish()
+}
+
+// end ends the current tick, if not ended yet, with the error it failed
+// with, if any.
+func (t *scheduleTrace) end(err error) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.current == nil {
+		return
+	}
+	if err != nil && t.current.Err == "" {
+		t.current.Err = err.Error()
+	}
+	t.finish()
+}
+
+// finish adds the current tick to the ring buffer, replacing the oldest
+// one once full.
+func (t *scheduleTrace) finish() {
+	tick := *t.current
+	t.current = nil
+	tick.Duration = time.Since(tick.Started)
+	tick.Overran = t.interval > 0 && tick.Duration > t.interval
+	if len(t.ticks) < cap(t.ticks) {
+		t.ticks = append(t.ticks, tick)
+		return
+	}
+	t.ticks[t.next] = tick
+	t.next = (t.next + 1) % len(t.ticks)
+}
+
+// list returns the ticks traced, oldest first.
+func (t *scheduleTrace) list() []Tick {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.ticks) == 0 {
+		return nil
+	}
+	return append(append([]Tick(nil), t.ticks[t.next:]...), t.ticks[:t.next]...)
+}
@@ -0,0 +1,69 @@
+package signalfx
+
+import (
+	"errors"
+	"time"
+
+	metrics "github.com/rcrowley/go-metrics"
+	. "gopkg.in/check.v1"
+)
+
+func (s *Zuite) TestScheduleTrace(c *C) {
+	t := newScheduleTrace()
+	start := time.Date(2017, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	t.begin(start, 15*time.Second)
+	t.flushed(FlushStats{Emitted: 3, Suppressed: 2})
+	t.end(nil)
+	t.begin(start.Add(30*time.Second), 15*time.Second)
+	t.skip("paused")
+	t.end(errors.New("ignored"))
+	t.begin(start.Add(time.Minute), 15*time.Second)
+	t.end(errors.New("unreachable"))
+
+	ticks := t.list()
+	c.Assert(ticks, HasLen, 3)
+	c.Assert(ticks[0].Emitted, Equals, 3)
+	c.Assert(ticks[0].Suppressed, Equals, 2)
+	c.Assert(ticks[0].Missed, Equals, 0)
+	c.Assert(ticks[1].Missed, Equals, 1)
+	c.Assert(ticks[1].Skipped, Equals, "paused")
+	c.Assert(ticks[1].Err, Equals, "")
+	c.Assert(ticks[2].Scheduled, Equals, start.Add(time.Minute))
+	c.Assert(ticks[2].Err, Equals, "unreachable")
+}
+
+func (s *Zuite) TestScheduleTrace_ring(c *C) {
+	t := newScheduleTrace()
+	start := time.Date(2017, 3, 1, 10, 0, 0, 0, time.UTC)
+	for i := 0; i < scheduleTraceSize+5; i++ {
+		t.begin(start.Add(time.Duration(i)*time.Second), time.Second)
+		t.end(nil)
+	}
+	ticks := t.list()
+	c.Assert(ticks, HasLen, scheduleTraceSize)
+	c.Assert(ticks[0].Scheduled, Equals, start.Add(5*time.Second))
+	c.Assert(ticks[scheduleTraceSize-1].Scheduled, Equals, start.Add((scheduleTraceSize+4)*time.Second))
+}
+
+func (s *Zuite) TestAdmin_schedule(c *C) {
+	admin := NewAdmin()
+	p := newPublisher("", Options{Admin: admin, CircuitBreakerThreshold: 1})
+	p.sender = &fakeSender{err: errors.New("unreachable")}
+	r := metrics.NewRegistry()
+	metrics.GetOrRegisterCounter("requests", r).Inc(1)
+
+	now := time.Now()
+	p.schedule.begin(now, time.Minute)
+	err := p.single(r)
+	c.Assert(err, ErrorMatches, "unreachable")
+	p.schedule.end(err)
+	p.schedule.begin(now.Add(time.Minute), time.Minute)
+	c.Assert(p.single(r), IsNil)
+	p.schedule.end(nil)
+
+	ticks := admin.Schedule()
+	c.Assert(ticks, HasLen, 2)
+	c.Assert(ticks[0].Err, Equals, "unreachable")
+	c.Assert(ticks[1].Skipped, Equals, "circuit open")
+}
@@ -0,0 +1,70 @@
+package signalfx
+
+import (
+	metrics "github.com/rcrowley/go-metrics"
+)
+
+// selfMetrics are the metrics the publisher records about itself. A nil
+// *selfMetrics records nothing.
+type selfMetrics struct {
+	bytesSent    metrics.Counter
+	sent         metrics.Counter
+	suppressed   metrics.Counter
+	lateDropped  metrics.Counter
+	rejected     metrics.Counter
+	flushes      metrics.Counter
+	flushErrors  metrics.Counter
+	flushBytes   metrics.Gauge
+	flushLatency metrics.Timer
+
+	// The cost of flushes, nil unless profiled.
+	flushAllocBytes metrics.Gauge
+	flushAllocs     metrics.Gauge
+	flushCPU        metrics.Timer
+}
+
+func newSelfMetrics(r metrics.Registry, profile bool) *selfMetrics {
+	if r == nil {
+		return nil
+	}
+	m := &selfMetrics{
+		bytesSent:    metrics.GetOrRegisterCounter("signalfx.bytes_sent", r),
+		sent:         metrics.GetOrRegisterCounter("signalfx.datapoints_sent", r),
+		suppressed:   metrics.GetOrRegisterCounter("signalfx.datapoints_suppressed", r),
+		lateDropped:  metrics.GetOrRegisterCounter("signalfx.late_dropped", r),
+		rejected:     metrics.GetOrRegisterCounter("signalfx.rejected", r),
+		flushes:      metrics.GetOrRegisterCounter("signalfx.flushes", r),
+		flushErrors:  metrics.GetOrRegisterCounter("signalfx.flush_errors", r),
+		flushBytes:   metrics.GetOrRegisterGauge("signalfx.flush_bytes", r),
+		flushLatency: metrics.GetOrRegisterTimer("signalfx.flush_latency", r),
+	}
+	if profile {
+		m.flushAllocBytes = metrics.GetOrRegisterGauge("signalfx.flush_alloc_bytes", r)
+		m.flushAllocs = metrics.GetOrRegisterGauge("signalfx.flush_allocs", r)
+		m.flushCPU = metrics.GetOrRegisterTimer("signalfx.flush_cpu", r)
+	}
+	return m
+}
+
+// record updates the self-metrics with the stats of a flush.
+func (m *selfMetrics) record(stats FlushStats) {
+	if m == nil {
+		return
+	}
+	m.bytesSent.Inc(stats.Bytes)
+	m.sent.Inc(int64(stats.Emitted))
+	m.suppressed.Inc(int64(stats.Suppressed))
+	m.lateDropped.Inc(int64(stats.Late))
+	m.rejected.Inc(int64(stats.Rejected))
+	m.flushes.Inc(1)
+	if stats.Err != nil {
+		m.flushErrors.Inc(1)
+	}
+	m.flushBytes.Update(stats.Bytes)
+	m.flushLatency.Update(stats.Latency)
+	if m.flushCPU != nil {
+		m.flushAllocBytes.Update(stats.AllocBytes)
+		m.flushAllocs.Update(stats.Allocs)
+		m.flushCPU.Update(stats.CPU)
+	}
+}
@@ -0,0 +1,47 @@
+package signalfx
+
+import (
+	"errors"
+	"time"
+
+	metrics "github.com/rcrowley/go-metrics"
+	. "gopkg.in/check.v1"
+)
+
+func (s *Zuite) TestSelfMetrics_record(c *C) {
+	r := metrics.NewRegistry()
+	m := newSelfMetrics(r, false)
+
+	m.record(FlushStats{Bytes: 100, Emitted: 5, Suppressed: 2, Latency: 30 * time.Millisecond})
+	m.record(FlushStats{Bytes: 20, Suppressed: 7, Latency: 10 * time.Millisecond, Err: errors.New("unreachable")})
+
+	c.Assert(r.Get("signalfx.bytes_sent").(metrics.Counter).Count(), Equals, int64(120))
+	c.Assert(r.Get("signalfx.datapoints_sent").(metrics.Counter).Count(), Equals, int64(5))
+	c.Assert(r.Get("signalfx.datapoints_suppressed").(metrics.Counter).Count(), Equals, int64(9))
+	c.Assert(r.Get("signalfx.flushes").(metrics.Counter).Count(), Equals, int64(2))
+	c.Assert(r.Get("signalfx.flush_errors").(metrics.Counter).Count(), Equals, int64(1))
+	c.Assert(r.Get("signalfx.flush_bytes").(metrics.Gauge).Value(), Equals, int64(20))
+
+	latency := r.Get("signalfx.flush_latency").(metrics.Timer)
+	c.Assert(latency.Count(), Equals, int64(2))
+	c.Assert(latency.Max(), Equals, int64(30*time.Millisecond))
+	c.Assert(r.Get("signalfx.flush_cpu"), IsNil)
+}
+
+func (s *Zuite) TestSelfMetrics_profile(c *C) {
+	r := metrics.NewRegistry()
+	m := newSelfMetrics(r, true)
+
+	m.record(FlushStats{AllocBytes: 4096, Allocs: 12, CPU: 2 * time.Millisecond})
+
+	c.Assert(r.Get("signalfx.flush_alloc_bytes").(metrics.Gauge).Value(), Equals, int64(4096))
+	c.Assert(r.Get("signalfx.flush_allocs").(metrics.Gauge).Value(), Equals, int64(12))
+	c.Assert(r.Get("signalfx.flush_cpu").(metrics.Timer).Max(), Equals, int64(2*time.Millisecond))
+}
+
+func (s *Zuite) TestSelfMetrics_disabled(c *C) {
+	m := newSelfMetrics(nil, false)
+
+	c.Assert(m, IsNil)
+	m.record(FlushStats{Bytes: 100})
+}
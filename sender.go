@@ -0,0 +1,29 @@
+package signalfx
+
+import (
+	"context"
+
+	metrics "github.com/rcrowley/go-metrics"
+	"github.com/signalfx/golib/datapoint"
+	"github.com/signalfx/golib/event"
+	"github.com/signalfx/golib/sfxclient"
+)
+
+// DatapointSender sends datapoints and events to SignalFX. It is implemented
+// by *sfxclient.HTTPSink, and by fakes in tests.
+type DatapointSender interface {
+	AddDatapoints(ctx context.Context, points []*datapoint.Datapoint) error
+	AddEvents(ctx context.Context, events []*event.Event) error
+}
+
+var _ DatapointSender = (*sfxclient.HTTPSink)(nil)
+
+// NewWithSender creates a publisher of the registry, as New does, sending
+// the datapoints through the sender rather than a client created from the
+// options, e.g. to test the publishing logic without network. The options
+// configuring the client, such as the proxy or TLS settings, are ignored.
+func NewWithSender(r metrics.Registry, sender DatapointSender, options ...Options) *Publisher {
+	pub := New(r, "", singleOptions("NewWithSender", options))
+	pub.p.sender = sender
+	return pub
+}
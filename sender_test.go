@@ -0,0 +1,84 @@
+package signalfx
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	metrics "github.com/rcrowley/go-metrics"
+	"github.com/signalfx/golib/datapoint"
+	"github.com/signalfx/golib/event"
+	. "gopkg.in/check.v1"
+)
+
+// fakeSender records the datapoints sent, failing when err is set.
+type fakeSender struct {
+	sent []*datapoint.Datapoint
+	err  error
+}
+
+func (f *fakeSender) AddDatapoints(_ context.Context, points []*datapoint.Datapoint) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.sent = append(f.sent, points...)
+	return nil
+}
+
+func (f *fakeSender) AddEvents(context.Context, []*event.Event) error {
+	return f.err
+}
+
+// newTestPublisher creates a publisher with the options, sending to a
+// fakeSender.
+func newTestPublisher(opt Options) (*publisher, *fakeSender) {
+	sender := &fakeSender{}
+	p := newPublisher("", opt)
+	p.sender = sender
+	return p, sender
+}
+
+// batchNames returns the sorted names of the points of the batch.
+func batchNames(batch Batch) []string {
+	var names []string
+	for _, point := range batch {
+		names = append(names, point.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (s *Zuite) TestSingle_injectedSender(c *C) {
+	p, sender := newTestPublisher(Options{})
+	sender.err = errors.New("unreachable")
+
+	r := metrics.NewRegistry()
+	metrics.GetOrRegisterCounter("requests", r).Inc(3)
+	c.Assert(p.single(r), ErrorMatches, "unreachable")
+	p.disconnect()
+	c.Assert(p.sender, Equals, DatapointSender(sender))
+	c.Assert(p.last.counters, HasLen, 0)
+
+	sender.err = nil
+	c.Assert(p.single(r), IsNil)
+	c.Assert(sender.sent, HasLen, 1)
+	c.Assert(p.last.counters, DeepEquals, map[string]int64{"requests": 3})
+
+	c.Assert(p.single(r), IsNil)
+	c.Assert(sender.sent, HasLen, 1)
+}
+
+func (s *Zuite) TestNewWithSender(c *C) {
+	sender := &fakeSender{}
+	r := metrics.NewRegistry()
+	metrics.GetOrRegisterCounter("requests", r).Inc(3)
+
+	publisher := NewWithSender(r, sender, Options{DiffFrequency: time.Hour})
+	publisher.Start()
+	c.Assert(publisher.Close(), IsNil)
+
+	// The final flush went through the sender, which is kept when closing.
+	c.Assert(sender.sent, HasLen, 1)
+	c.Assert(publisher.p.sender, Equals, DatapointSender(sender))
+}
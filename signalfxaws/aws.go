@@ -0,0 +1,47 @@
+// Package signalfxaws records the requests made with the AWS SDK for Go v2,
+// to be published to SignalFX.
+package signalfxaws
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/smithy-go"
+	"github.com/aws/smithy-go/middleware"
+	signalfx "github.com/pascallouisperez/go-metrics-signalfx"
+	metrics "github.com/rcrowley/go-metrics"
+)
+
+// Middleware returns an API option adding middleware which records the
+// requests made in r, see signalfx.RecordAWSRequest. The latency includes
+// retries:
+//
+// 	cfg.APIOptions = append(cfg.APIOptions, signalfxaws.Middleware(metrics.DefaultRegistry))
+func Middleware(r metrics.Registry) func(*middleware.Stack) error {
+	return func(stack *middleware.Stack) error {
+		// After the service metadata is registered in the context.
+		return stack.Initialize.Add(middleware.InitializeMiddlewareFunc("SignalFXMetrics", func(
+			ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler,
+		) (middleware.InitializeOutput, middleware.Metadata, error) {
+			start := time.Now()
+			out, metadata, err := next.HandleInitialize(ctx, in)
+			signalfx.RecordAWSRequest(r, awsmiddleware.GetServiceID(ctx), awsmiddleware.GetOperationName(ctx), errorCode(err), time.Since(start))
+			return out, metadata, err
+		}), middleware.After)
+	}
+}
+
+// errorCode returns the code of the error returned by the service, "unknown"
+// for other errors, or an empty string if there is no error.
+func errorCode(err error) string {
+	if err == nil {
+		return ""
+	}
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.ErrorCode()
+	}
+	return "unknown"
+}
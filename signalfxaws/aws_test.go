@@ -0,0 +1,31 @@
+package signalfxaws
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aws/smithy-go"
+	"github.com/aws/smithy-go/middleware"
+	metrics "github.com/rcrowley/go-metrics"
+	. "gopkg.in/check.v1"
+)
+
+func Test(t *testing.T) { TestingT(t) }
+
+type Zuite struct{}
+
+var _ = Suite(&Zuite{})
+
+func (s *Zuite) TestMiddleware(c *C) {
+	stack := middleware.NewStack("GetItem", nil)
+	c.Assert(Middleware(metrics.NewRegistry())(stack), IsNil)
+
+	_, ok := stack.Initialize.Get("SignalFXMetrics")
+	c.Assert(ok, Equals, true)
+}
+
+func (s *Zuite) TestErrorCode(c *C) {
+	c.Assert(errorCode(nil), Equals, "")
+	c.Assert(errorCode(errors.New("connection reset")), Equals, "unknown")
+	c.Assert(errorCode(&smithy.GenericAPIError{Code: "ThrottlingException"}), Equals, "ThrottlingException")
+}
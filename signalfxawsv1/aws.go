@@ -0,0 +1,45 @@
+// Package signalfxawsv1 records the requests made with the AWS SDK for Go
+// v1, to be published to SignalFX.
+package signalfxawsv1
+
+import (
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	signalfx "github.com/pascallouisperez/go-metrics-signalfx"
+	metrics "github.com/rcrowley/go-metrics"
+)
+
+// HandlerName is the name of the handler added by AddHandlers.
+const HandlerName = "signalfx.RecordAWSRequest"
+
+// AddHandlers adds a handler recording the requests made in r, see
+// signalfx.RecordAWSRequest. The latency includes retries:
+//
+// 	sess := session.Must(session.NewSession())
+// 	signalfxawsv1.AddHandlers(metrics.DefaultRegistry, &sess.Handlers)
+func AddHandlers(r metrics.Registry, handlers *request.Handlers) {
+	handlers.Complete.PushBackNamed(request.NamedHandler{
+		Name: HandlerName,
+		Fn: func(req *request.Request) {
+			var operation string
+			if req.Operation != nil {
+				operation = req.Operation.Name
+			}
+			signalfx.RecordAWSRequest(r, req.ClientInfo.ServiceID, operation, errorCode(req.Error), time.Since(req.Time))
+		},
+	})
+}
+
+// errorCode returns the code of the error, or an empty string if there is no
+// error.
+func errorCode(err error) string {
+	if err == nil {
+		return ""
+	}
+	if awsErr, ok := err.(awserr.Error); ok {
+		return awsErr.Code()
+	}
+	return "unknown"
+}
@@ -0,0 +1,37 @@
+package signalfxawsv1
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/client/metadata"
+	"github.com/aws/aws-sdk-go/aws/request"
+	signalfx "github.com/pascallouisperez/go-metrics-signalfx"
+	metrics "github.com/rcrowley/go-metrics"
+	. "gopkg.in/check.v1"
+)
+
+func Test(t *testing.T) { TestingT(t) }
+
+type Zuite struct{}
+
+var _ = Suite(&Zuite{})
+
+func (s *Zuite) TestAddHandlers(c *C) {
+	r := metrics.NewRegistry()
+	var handlers request.Handlers
+	AddHandlers(r, &handlers)
+
+	handlers.Complete.Run(&request.Request{
+		ClientInfo: metadata.ClientInfo{ServiceID: "S3"},
+		Operation:  &request.Operation{Name: "GetObject"},
+		Time:       time.Now(),
+		Error:      awserr.New("NoSuchKey", "not found", nil),
+	})
+
+	dims := map[string]string{"service": "S3", "operation": "GetObject"}
+	c.Assert(r.Get(signalfx.NameWithDimensions(signalfx.AWSRequestsMetric, dims)).(metrics.Timer).Count(), Equals, int64(1))
+	dims["code"] = "NoSuchKey"
+	c.Assert(r.Get(signalfx.NameWithDimensions(signalfx.AWSErrorsMetric, dims)).(metrics.Counter).Count(), Equals, int64(1))
+}
@@ -0,0 +1,32 @@
+// Package signalfxchi records the requests served by chi routers, with their
+// route pattern, to be published to SignalFX.
+package signalfxchi
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	signalfx "github.com/pascallouisperez/go-metrics-signalfx"
+	metrics "github.com/rcrowley/go-metrics"
+)
+
+// Middleware returns chi middleware recording the requests served in r,
+// see signalfx.RecordHTTPRequest:
+//
+// 	router := chi.NewRouter()
+// 	router.Use(signalfxchi.Middleware(metrics.DefaultRegistry))
+func Middleware(r metrics.Registry) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			start := time.Now()
+			recorder := &signalfx.StatusRecorder{ResponseWriter: w}
+			next.ServeHTTP(recorder, req)
+			route := "unmatched"
+			if rctx := chi.RouteContext(req.Context()); rctx != nil && rctx.RoutePattern() != "" {
+				route = rctx.RoutePattern()
+			}
+			signalfx.RecordHTTPRequest(r, req.Method, route, recorder.Status(), time.Since(start))
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package signalfxchi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	signalfx "github.com/pascallouisperez/go-metrics-signalfx"
+	metrics "github.com/rcrowley/go-metrics"
+	. "gopkg.in/check.v1"
+)
+
+func Test(t *testing.T) { TestingT(t) }
+
+type Zuite struct{}
+
+var _ = Suite(&Zuite{})
+
+func (s *Zuite) TestMiddleware(c *C) {
+	r := metrics.NewRegistry()
+	router := chi.NewRouter()
+	router.Use(Middleware(r))
+	router.Get("/users/{id}", func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/1", nil))
+
+	counter := r.Get(signalfx.NameWithDimensions(signalfx.HTTPResponsesMetric, map[string]string{
+		"method": "GET",
+		"route":  "/users/{id}",
+		"status": "201",
+	}))
+	c.Assert(counter, NotNil)
+	c.Assert(counter.(metrics.Counter).Count(), Equals, int64(1))
+}
@@ -0,0 +1,114 @@
+// Package signalfxconfig reloads the configuration of a publisher from a file
+// each time it is written, so that metrics can be tuned fleet-wide through
+// configuration management, without restarts.
+package signalfxconfig
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/fsnotify/fsnotify"
+	signalfx "github.com/pascallouisperez/go-metrics-signalfx"
+	metrics "github.com/rcrowley/go-metrics"
+)
+
+// Watcher reloads a configuration file as it changes.
+type Watcher struct {
+	path    string
+	admin   *signalfx.Admin
+	logger  metrics.Logger
+	watcher *fsnotify.Watcher
+	done    chan struct{}
+}
+
+// Watch loads the JSON configuration file, see signalfx.Config, into the
+// publisher administered by admin, and reloads it each time the file is
+// written or replaced:
+//
+// 	admin := signalfx.NewAdmin()
+// 	watcher, err := signalfxconfig.Watch("/etc/metrics.json", admin, logger)
+// 	if err != nil {
+// 		log.Fatal(err)
+// 	}
+// 	defer watcher.Close()
+// 	go signalfx.PublishToSignalFx(metrics.DefaultRegistry, "<auth_token>", signalfx.Options{
+// 		Admin: admin,
+// 	})
+//
+// Failing to load the file later on keeps the last configuration, and the
+// error is logged through the logger, if any.
+func Watch(path string, admin *signalfx.Admin, logger metrics.Logger) (*Watcher, error) {
+	w := &Watcher{
+		path:   filepath.Clean(path),
+		admin:  admin,
+		logger: logger,
+		done:   make(chan struct{}),
+	}
+	if err := w.load(); err != nil {
+		return nil, err
+	}
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	// The directory is watched, rather than the file, so that replacing
+	// the file, as configuration management tools do, is noticed.
+	if err := watcher.Add(filepath.Dir(w.path)); err != nil {
+		watcher.Close()
+		return nil, err
+	}
+	w.watcher = watcher
+	go w.watch()
+	return w, nil
+}
+
+// Close stops watching the file.
+func (w *Watcher) Close() error {
+	err := w.watcher.Close()
+	<-w.done
+	return err
+}
+
+func (w *Watcher) watch() {
+	defer close(w.done)
+	for {
+		select {
+		case e, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			if filepath.Clean(e.Name) != w.path || !e.Has(fsnotify.Write) && !e.Has(fsnotify.Create) {
+				continue
+			}
+			if err := w.load(); err != nil {
+				w.logf("Unable to reload %s: %s.", w.path, err)
+			}
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			w.logf("Unable to watch %s: %s.", w.path, err)
+		}
+	}
+}
+
+// load reads the file, and reloads it into the publisher.
+func (w *Watcher) load() error {
+	data, err := os.ReadFile(w.path)
+	if err != nil {
+		return err
+	}
+	var config signalfx.Config
+	if err := json.Unmarshal(data, &config); err != nil {
+		return err
+	}
+	w.admin.Reload(config)
+	return nil
+}
+
+func (w *Watcher) logf(format string, v ...interface{}) {
+	if w.logger != nil {
+		w.logger.Printf(format, v...)
+	}
+}
@@ -0,0 +1,60 @@
+package signalfxconfig
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	signalfx "github.com/pascallouisperez/go-metrics-signalfx"
+	. "gopkg.in/check.v1"
+)
+
+func Test(t *testing.T) { TestingT(t) }
+
+type Zuite struct{}
+
+var _ = Suite(&Zuite{})
+
+func (s *Zuite) TestWatch(c *C) {
+	path := filepath.Join(c.MkDir(), "metrics.json")
+	c.Assert(os.WriteFile(path, []byte(`{"diff_frequency": "30s", "exclude": ["go."]}`), 0644), IsNil)
+
+	admin := signalfx.NewAdmin()
+	w, err := Watch(path, admin, nil)
+	c.Assert(err, IsNil)
+	defer w.Close()
+	c.Assert(admin.Config().DiffFrequency, Equals, 30*time.Second)
+	c.Assert(admin.Config().Exclude, DeepEquals, []string{"go."})
+
+	// Replacing the file, as configuration management tools do.
+	replacement := path + ".tmp"
+	c.Assert(os.WriteFile(replacement, []byte(`{"dpm_budget": 1000}`), 0644), IsNil)
+	c.Assert(os.Rename(replacement, path), IsNil)
+	waitFor(c, func() bool { return admin.Config().DPMBudget == 1000 })
+	c.Assert(admin.Config().DiffFrequency, Equals, time.Duration(0))
+
+	// Invalid configurations are not applied.
+	c.Assert(os.WriteFile(path, []byte(`{"diff_frequency": "often"}`), 0644), IsNil)
+	c.Assert(os.WriteFile(path, []byte(`{"dpm_budget": 2000}`), 0644), IsNil)
+	waitFor(c, func() bool { return admin.Config().DPMBudget == 2000 })
+}
+
+func (s *Zuite) TestWatch_invalid(c *C) {
+	path := filepath.Join(c.MkDir(), "metrics.json")
+	c.Assert(os.WriteFile(path, []byte(`{"full_frequency": "-1m"}`), 0644), IsNil)
+
+	_, err := Watch(path, signalfx.NewAdmin(), nil)
+	c.Assert(err, ErrorMatches, `full_frequency: "-1m" is not positive`)
+
+	_, err = Watch(filepath.Join(c.MkDir(), "missing.json"), signalfx.NewAdmin(), nil)
+	c.Assert(err, NotNil)
+}
+
+func waitFor(c *C, condition func() bool) {
+	for deadline := time.Now().Add(5 * time.Second); !condition(); time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			c.Fatal("timed out")
+		}
+	}
+}
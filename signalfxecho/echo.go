@@ -0,0 +1,37 @@
+// Package signalfxecho records the requests served by echo, with their route
+// path, to be published to SignalFX.
+package signalfxecho
+
+import (
+	"time"
+
+	"github.com/labstack/echo/v4"
+	signalfx "github.com/pascallouisperez/go-metrics-signalfx"
+	metrics "github.com/rcrowley/go-metrics"
+)
+
+// Middleware returns echo middleware recording the requests served in r,
+// see signalfx.RecordHTTPRequest:
+//
+// 	e := echo.New()
+// 	e.Use(signalfxecho.Middleware(metrics.DefaultRegistry))
+func Middleware(r metrics.Registry) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			start := time.Now()
+			err := next(c)
+			if err != nil {
+				// Let echo write the error response, to record its status.
+				// The error is still returned, for outer middleware, and
+				// echo does not write a committed response again.
+				c.Error(err)
+			}
+			route := c.Path()
+			if route == "" {
+				route = "unmatched"
+			}
+			signalfx.RecordHTTPRequest(r, c.Request().Method, route, c.Response().Status, time.Since(start))
+			return err
+		}
+	}
+}
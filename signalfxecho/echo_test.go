@@ -0,0 +1,56 @@
+package signalfxecho
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	signalfx "github.com/pascallouisperez/go-metrics-signalfx"
+	metrics "github.com/rcrowley/go-metrics"
+	. "gopkg.in/check.v1"
+)
+
+func Test(t *testing.T) { TestingT(t) }
+
+type Zuite struct{}
+
+var _ = Suite(&Zuite{})
+
+func (s *Zuite) TestMiddleware(c *C) {
+	r := metrics.NewRegistry()
+	e := echo.New()
+	e.Use(Middleware(r))
+	e.GET("/users/:id", func(c echo.Context) error {
+		return echo.ErrForbidden
+	})
+
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/1", nil))
+
+	counter := r.Get(signalfx.NameWithDimensions(signalfx.HTTPResponsesMetric, map[string]string{
+		"method": "GET",
+		"route":  "/users/:id",
+		"status": "403",
+	}))
+	c.Assert(counter, NotNil)
+	c.Assert(counter.(metrics.Counter).Count(), Equals, int64(1))
+}
+
+func (s *Zuite) TestMiddleware_error(c *C) {
+	var returned error
+	e := echo.New()
+	e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			returned = next(c)
+			return returned
+		}
+	})
+	e.Use(Middleware(metrics.NewRegistry()))
+	e.GET("/", func(c echo.Context) error {
+		return echo.ErrForbidden
+	})
+
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	c.Assert(returned, Equals, echo.ErrForbidden)
+	c.Assert(w.Code, Equals, 403)
+}
@@ -0,0 +1,28 @@
+// Package signalfxgin records the requests served by gin, with their route
+// path, to be published to SignalFX.
+package signalfxgin
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	signalfx "github.com/pascallouisperez/go-metrics-signalfx"
+	metrics "github.com/rcrowley/go-metrics"
+)
+
+// Middleware returns gin middleware recording the requests served in r,
+// see signalfx.RecordHTTPRequest:
+//
+// 	router := gin.New()
+// 	router.Use(signalfxgin.Middleware(metrics.DefaultRegistry))
+func Middleware(r metrics.Registry) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		signalfx.RecordHTTPRequest(r, c.Request.Method, route, c.Writer.Status(), time.Since(start))
+	}
+}
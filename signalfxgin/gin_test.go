@@ -0,0 +1,37 @@
+package signalfxgin
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	signalfx "github.com/pascallouisperez/go-metrics-signalfx"
+	metrics "github.com/rcrowley/go-metrics"
+	. "gopkg.in/check.v1"
+)
+
+func Test(t *testing.T) { TestingT(t) }
+
+type Zuite struct{}
+
+var _ = Suite(&Zuite{})
+
+func (s *Zuite) TestMiddleware(c *C) {
+	gin.SetMode(gin.TestMode)
+	r := metrics.NewRegistry()
+	router := gin.New()
+	router.Use(Middleware(r))
+	router.GET("/users/:id", func(c *gin.Context) {
+		c.Status(202)
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/1", nil))
+
+	counter := r.Get(signalfx.NameWithDimensions(signalfx.HTTPResponsesMetric, map[string]string{
+		"method": "GET",
+		"route":  "/users/:id",
+		"status": "202",
+	}))
+	c.Assert(counter, NotNil)
+	c.Assert(counter.(metrics.Counter).Count(), Equals, int64(1))
+}
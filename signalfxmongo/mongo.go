@@ -0,0 +1,64 @@
+// Package signalfxmongo monitors the connection pools of the MongoDB driver,
+// to be published to SignalFX.
+package signalfxmongo
+
+import (
+	"sync"
+
+	signalfx "github.com/pascallouisperez/go-metrics-signalfx"
+	metrics "github.com/rcrowley/go-metrics"
+	"go.mongodb.org/mongo-driver/v2/event"
+)
+
+// PoolMonitor returns a monitor recording in r the activity of the connection
+// pools of a client, with the address of the server as the address
+// dimension:
+//
+// 	opts := options.Client().ApplyURI(uri).
+// 		SetPoolMonitor(signalfxmongo.PoolMonitor(metrics.DefaultRegistry))
+//
+// It records the connection checkouts, mongo.pool.checkouts, their latency,
+// mongo.pool.checkout_latency, and failures by reason,
+// mongo.pool.checkout_failures, as well as the number of connections in the
+// pool, mongo.pool.size, and checked out of it, mongo.pool.in_use, and the
+// number of times the pool was cleared, mongo.pool.cleared.
+func PoolMonitor(r metrics.Registry) *event.PoolMonitor {
+	m := &monitor{r: r, values: make(map[string]int64)}
+	return &event.PoolMonitor{Event: m.event}
+}
+
+type monitor struct {
+	r      metrics.Registry
+	mu     sync.Mutex
+	values map[string]int64
+}
+
+func (m *monitor) event(e *event.PoolEvent) {
+	dims := map[string]string{"address": e.Address}
+	switch e.Type {
+	case event.ConnectionCreated:
+		m.add("mongo.pool.size", dims, 1)
+	case event.ConnectionClosed:
+		m.add("mongo.pool.size", dims, -1)
+	case event.ConnectionCheckedOut:
+		metrics.GetOrRegisterCounter(signalfx.NameWithDimensions("mongo.pool.checkouts", dims), m.r).Inc(1)
+		metrics.GetOrRegisterTimer(signalfx.NameWithDimensions("mongo.pool.checkout_latency", dims), m.r).Update(e.Duration)
+		m.add("mongo.pool.in_use", dims, 1)
+	case event.ConnectionCheckedIn:
+		m.add("mongo.pool.in_use", dims, -1)
+	case event.ConnectionCheckOutFailed:
+		dims["reason"] = e.Reason
+		metrics.GetOrRegisterCounter(signalfx.NameWithDimensions("mongo.pool.checkout_failures", dims), m.r).Inc(1)
+	case event.ConnectionPoolCleared:
+		metrics.GetOrRegisterCounter(signalfx.NameWithDimensions("mongo.pool.cleared", dims), m.r).Inc(1)
+	}
+}
+
+// add adds delta to the gauge.
+func (m *monitor) add(metric string, dims map[string]string, delta int64) {
+	name := signalfx.NameWithDimensions(metric, dims)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.values[name] += delta
+	metrics.GetOrRegisterGauge(name, m.r).Update(m.values[name])
+}
@@ -0,0 +1,39 @@
+package signalfxmongo
+
+import (
+	"testing"
+	"time"
+
+	metrics "github.com/rcrowley/go-metrics"
+	"go.mongodb.org/mongo-driver/v2/event"
+	. "gopkg.in/check.v1"
+)
+
+func Test(t *testing.T) { TestingT(t) }
+
+type Zuite struct{}
+
+var _ = Suite(&Zuite{})
+
+func (s *Zuite) TestPoolMonitor(c *C) {
+	r := metrics.NewRegistry()
+	monitor := PoolMonitor(r)
+	for _, e := range []event.PoolEvent{
+		{Type: event.ConnectionCreated, Address: "db:27017"},
+		{Type: event.ConnectionCreated, Address: "db:27017"},
+		{Type: event.ConnectionCheckedOut, Address: "db:27017", Duration: time.Millisecond},
+		{Type: event.ConnectionCheckedOut, Address: "db:27017", Duration: 3 * time.Millisecond},
+		{Type: event.ConnectionCheckedIn, Address: "db:27017"},
+		{Type: event.ConnectionClosed, Address: "db:27017"},
+		{Type: event.ConnectionCheckOutFailed, Address: "db:27017", Reason: event.ReasonTimedOut},
+	} {
+		e := e
+		monitor.Event(&e)
+	}
+
+	c.Assert(r.Get("mongo.pool.size[address=db:27017]").(metrics.Gauge).Value(), Equals, int64(1))
+	c.Assert(r.Get("mongo.pool.in_use[address=db:27017]").(metrics.Gauge).Value(), Equals, int64(1))
+	c.Assert(r.Get("mongo.pool.checkouts[address=db:27017]").(metrics.Counter).Count(), Equals, int64(2))
+	c.Assert(r.Get("mongo.pool.checkout_latency[address=db:27017]").(metrics.Timer).Max(), Equals, int64(3*time.Millisecond))
+	c.Assert(r.Get("mongo.pool.checkout_failures[address=db:27017,reason=timeout]").(metrics.Counter).Count(), Equals, int64(1))
+}
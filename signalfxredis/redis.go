@@ -0,0 +1,47 @@
+// Package signalfxredis collects the connection pool statistics of go-redis
+// clients, to be published to SignalFX.
+package signalfxredis
+
+import (
+	signalfx "github.com/pascallouisperez/go-metrics-signalfx"
+	metrics "github.com/rcrowley/go-metrics"
+	"github.com/redis/go-redis/v9"
+)
+
+// Pooler is a go-redis client with a connection pool, such as *redis.Client,
+// *redis.ClusterClient or *redis.Ring.
+type Pooler interface {
+	PoolStats() *redis.PoolStats
+}
+
+// RegisterPoolStats registers in r metrics reading the connection pool
+// statistics of the client, with the name of the client as the client
+// dimension. The number of hits, misses and timeouts are counters, the number
+// of total, idle and stale connections are gauges:
+//
+// 	signalfxredis.RegisterPoolStats(metrics.DefaultRegistry, "sessions", client)
+//
+// results in redis.pool.hits[client=sessions] and so on.
+func RegisterPoolStats(r metrics.Registry, name string, client Pooler) {
+	dims := map[string]string{"client": name}
+	for metric, stat := range map[string]func(*redis.PoolStats) uint32{
+		"redis.pool.hits":     func(s *redis.PoolStats) uint32 { return s.Hits },
+		"redis.pool.misses":   func(s *redis.PoolStats) uint32 { return s.Misses },
+		"redis.pool.timeouts": func(s *redis.PoolStats) uint32 { return s.Timeouts },
+	} {
+		stat := stat
+		r.Register(signalfx.NameWithDimensions(metric, dims), signalfx.NewFunctionalCounter(func() int64 {
+			return int64(stat(client.PoolStats()))
+		}))
+	}
+	for metric, stat := range map[string]func(*redis.PoolStats) uint32{
+		"redis.pool.total_conns": func(s *redis.PoolStats) uint32 { return s.TotalConns },
+		"redis.pool.idle_conns":  func(s *redis.PoolStats) uint32 { return s.IdleConns },
+		"redis.pool.stale_conns": func(s *redis.PoolStats) uint32 { return s.StaleConns },
+	} {
+		stat := stat
+		r.Register(signalfx.NameWithDimensions(metric, dims), metrics.NewFunctionalGauge(func() int64 {
+			return int64(stat(client.PoolStats()))
+		}))
+	}
+}
@@ -0,0 +1,35 @@
+package signalfxredis
+
+import (
+	"testing"
+
+	metrics "github.com/rcrowley/go-metrics"
+	"github.com/redis/go-redis/v9"
+	. "gopkg.in/check.v1"
+)
+
+func Test(t *testing.T) { TestingT(t) }
+
+type Zuite struct{}
+
+var _ = Suite(&Zuite{})
+
+type pooler redis.PoolStats
+
+func (p *pooler) PoolStats() *redis.PoolStats {
+	return (*redis.PoolStats)(p)
+}
+
+func (s *Zuite) TestRegisterPoolStats(c *C) {
+	r := metrics.NewRegistry()
+	stats := &pooler{Hits: 10, Misses: 2, TotalConns: 5, IdleConns: 3}
+	RegisterPoolStats(r, "sessions", stats)
+
+	c.Assert(r.Get("redis.pool.hits[client=sessions]").(metrics.Counter).Count(), Equals, int64(10))
+	stats.Hits = 11
+	c.Assert(r.Get("redis.pool.hits[client=sessions]").(metrics.Counter).Count(), Equals, int64(11))
+	c.Assert(r.Get("redis.pool.misses[client=sessions]").(metrics.Counter).Count(), Equals, int64(2))
+	c.Assert(r.Get("redis.pool.idle_conns[client=sessions]").(metrics.Gauge).Value(), Equals, int64(3))
+
+	var _ Pooler = redis.NewClient(&redis.Options{})
+}
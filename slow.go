@@ -0,0 +1,135 @@
+package signalfx
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SlowCollectionPolicy is what the publisher does when collecting the
+// metrics of the registries takes longer than the
+// SlowCollectionFraction of the DiffFrequency, e.g. because of GaugeFunc
+// callbacks blocking.
+type SlowCollectionPolicy int
+
+const (
+	// SlowCollectionLog logs the slowest metrics.
+	SlowCollectionLog SlowCollectionPolicy = iota
+
+	// SlowCollectionSkip skips the slowest metrics, as many as needed for
+	// the collection to take no longer than allowed, until the next full
+	// flush, reporting them.
+	SlowCollectionSkip
+
+	// SlowCollectionTimeBox stops collecting metrics once collection took
+	// as long as allowed, leaving the metrics not collected for the next
+	// flushes. The metric being collected is not interrupted.
+	SlowCollectionTimeBox
+)
+
+// slowReportSize is the number of the slowest metrics reported.
+const slowReportSize = 5
+
+// slowMetric is the time it took to collect a metric.
+type slowMetric struct {
+	source   origin
+	duration time.Duration
+}
+
+// slowCollector times the collection of metrics, see SlowCollectionPolicy.
+type slowCollector struct {
+	policy SlowCollectionPolicy
+
+	// skipped are the metrics skipped until the next full flush.
+	skipped map[origin]struct{}
+
+	// The collection in progress: when it started, how long it may take,
+	// and the metrics which took a noticeable part of it.
+	start   time.Time
+	allowed time.Duration
+	slowest []slowMetric
+}
+
+func newSlowCollector(opt Options) *slowCollector {
+	if opt.SlowCollectionFraction <= 0 {
+		return nil
+	}
+	return &slowCollector{policy: opt.SlowCollection, skipped: make(map[origin]struct{})}
+}
+
+// begin starts timing a collection.
+func (s *slowCollector) begin(opt Options) {
+	s.start = time.Now()
+	s.allowed = time.Duration(float64(opt.DiffFrequency) * opt.SlowCollectionFraction)
+	s.slowest = nil
+}
+
+// convert converts the metric of the update's source, unless skipped,
+// timing the conversion.
+func (s *slowCollector) convert(u *update, name string, i interface{}) {
+	_, skipped := s.skipped[u.source]
+	if skipped || (s.policy == SlowCollectionTimeBox && s.allowed > 0 && time.Since(s.start) > s.allowed) {
+		if u.present != nil {
+			u.present[name] = struct{}{}
+		}
+		u.stats.Slow++
+		return
+	}
+	start := time.Now()
+	u.metricToDatapoints(name, i)
+	if d := time.Since(start); d >= s.allowed/100 {
+		s.slowest = append(s.slowest, slowMetric{source: u.source, duration: d})
+	}
+}
+
+// end reports the collection if it took longer than allowed, skipping the
+// slowest metrics per the policy.
+func (s *slowCollector) end(u *update) {
+	elapsed := time.Since(s.start)
+	if s.allowed <= 0 || elapsed <= s.allowed {
+		return
+	}
+	sort.SliceStable(s.slowest, func(i, j int) bool {
+		return s.slowest[i].duration > s.slowest[j].duration
+	})
+	switch s.policy {
+	case SlowCollectionSkip:
+		var skipped []slowMetric
+		for _, m := range s.slowest {
+			if elapsed <= s.allowed {
+				break
+			}
+			s.skipped[m.source] = struct{}{}
+			skipped = append(skipped, m)
+			elapsed -= m.duration
+		}
+		u.p.logf(levelWarning, "WARNING: collecting metrics took %s, more than %s, skipping %d metrics until the next full flush: %s",
+			time.Since(s.start).Round(time.Millisecond), s.allowed, len(skipped), slowReport(skipped, len(skipped)))
+	case SlowCollectionTimeBox:
+		u.p.logf(levelWarning, "WARNING: collecting metrics took %s, more than %s, %d metrics left for the next flushes, slowest: %s",
+			elapsed.Round(time.Millisecond), s.allowed, u.stats.Slow, slowReport(s.slowest, slowReportSize))
+	default:
+		u.p.logf(levelWarning, "WARNING: collecting metrics took %s, more than %s, slowest: %s",
+			elapsed.Round(time.Millisecond), s.allowed, slowReport(s.slowest, slowReportSize))
+	}
+}
+
+// reset collects the skipped metrics again.
+func (s *slowCollector) reset() {
+	if s != nil && len(s.skipped) != 0 {
+		s.skipped = make(map[origin]struct{})
+	}
+}
+
+// slowReport formats at most n of the slow metrics.
+func slowReport(slowest []slowMetric, n int) string {
+	if len(slowest) > n {
+		slowest = slowest[:n]
+	}
+	parts := make([]string, 0, len(slowest))
+	for _, m := range slowest {
+		parts = append(parts, fmt.Sprintf("%s=%s", m.source, m.duration.Round(time.Microsecond)))
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,71 @@
+package signalfx
+
+import (
+	"time"
+
+	metrics "github.com/rcrowley/go-metrics"
+	. "gopkg.in/check.v1"
+)
+
+func slowRegistry(slow ...string) metrics.Registry {
+	r := metrics.NewRegistry()
+	metrics.GetOrRegisterCounter("fast", r).Inc(1)
+	for _, name := range slow {
+		r.Register(name, metrics.NewFunctionalGauge(func() int64 {
+			time.Sleep(20 * time.Millisecond)
+			return 1
+		}))
+	}
+	return r
+}
+
+func (s *Zuite) TestSlowCollection_log(c *C) {
+	logger := &recordingLogger{}
+	p := newPublisher("", Options{Logger: logger, DiffFrequency: 100 * time.Millisecond, SlowCollectionFraction: 0.1})
+	p.sender = &fakeSender{}
+
+	c.Assert(p.single(slowRegistry("blocking")), IsNil)
+	c.Assert(logger.lines, HasLen, 1)
+	c.Assert(logger.lines[0], Matches, "WARNING: collecting metrics took .*, more than 10ms, slowest: blocking#0=.*")
+}
+
+func (s *Zuite) TestSlowCollection_skip(c *C) {
+	var stats FlushStats
+	p, sender := newTestPublisher(Options{
+		DiffFrequency:          100 * time.Millisecond,
+		SlowCollectionFraction: 0.1,
+		SlowCollection:         SlowCollectionSkip,
+		OnFlush:                func(s FlushStats) { stats = s },
+	})
+	r := slowRegistry("blocking")
+
+	c.Assert(p.single(r), IsNil)
+	c.Assert(stats.Slow, Equals, 0)
+	c.Assert(p.single(r), IsNil)
+	c.Assert(stats.Slow, Equals, 1)
+
+	// The metric is collected again with the next full flush.
+	p.resetCaches()
+	sender.sent = nil
+	c.Assert(p.single(r), IsNil)
+	c.Assert(stats.Slow, Equals, 0)
+	c.Assert(sender.sent, HasLen, 2)
+}
+
+func (s *Zuite) TestSlowCollection_timeBox(c *C) {
+	var stats FlushStats
+	p, sender := newTestPublisher(Options{
+		DiffFrequency:          100 * time.Millisecond,
+		SlowCollectionFraction: 0.1,
+		SlowCollection:         SlowCollectionTimeBox,
+		OnFlush:                func(s FlushStats) { stats = s },
+	})
+
+	// Whichever slow metric is collected first, the other one is not.
+	c.Assert(p.single(slowRegistry("a", "b")), IsNil)
+	c.Assert(stats.Slow > 0, Equals, true)
+	c.Assert(sender.sent, HasLen, 3-stats.Slow)
+	_, a := newBatch(sender.sent).Get("a", nil)
+	_, b := newBatch(sender.sent).Get("b", nil)
+	c.Assert(a != b, Equals, true)
+}
@@ -0,0 +1,198 @@
+package signalfx
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Soak configures soak mode, mostly for pre-production, in which the
+// publisher keeps a rolling ledger of the values it sent, and periodically
+// verifies through the SignalFX API that they landed, producing delivery
+// accuracy reports. This qualifies the publisher before it is trusted with
+// critical metrics, such as billing ones.
+type Soak struct {
+	// Verifier queries the SignalFX API.
+	Verifier *Verifier
+
+	// Interval is the time between verifications. By default, this is 5
+	// minutes.
+	Interval time.Duration
+
+	// Delay is the time after which values sent are verified, leaving them
+	// time to be ingested. By default, this is a minute.
+	Delay time.Duration
+
+	// Sample is the number of values verified by each verification. The
+	// ledger keeps ten times as many values sent, the most recent ones. By
+	// default, this is 100.
+	Sample int
+
+	// OnReport, if set, is called with the report of each verification.
+	OnReport func(SoakReport)
+}
+
+// SoakReport is the outcome of a soak mode verification.
+type SoakReport struct {
+	// Verified is the number of values verified, and Delivered the number
+	// of those SignalFX has.
+	Verified  int
+	Delivered int
+
+	// Mismatched are the series of the values SignalFX has other values
+	// for, Missing those it has no values for, and Errors the number of
+	// values which could not be verified.
+	Mismatched []string
+	Missing    []string
+	Errors     int
+}
+
+// Accuracy is the share of the values verified which were delivered, 1 if
+// none was verified.
+func (r SoakReport) Accuracy() float64 {
+	if r.Verified == r.Errors {
+		return 1
+	}
+	return float64(r.Delivered) / float64(r.Verified-r.Errors)
+}
+
+func (r SoakReport) String() string {
+	return fmt.Sprintf("soak report: accuracy=%.2f%% verified=%d delivered=%d mismatched=%d missing=%d errors=%d mismatched_series=[%s] missing_series=[%s]",
+		r.Accuracy()*100, r.Verified, r.Delivered, len(r.Mismatched), len(r.Missing), r.Errors,
+		strings.Join(r.Mismatched, ", "), strings.Join(r.Missing, ", "))
+}
+
+// soakEntry is a value sent, recorded in the ledger.
+type soakEntry struct {
+	series string
+	value  float64
+	sent   time.Time
+}
+
+// soakLedger records the values sent, and verifies them in the background.
+type soakLedger struct {
+	soak Soak
+	logf func(format string, v ...interface{})
+
+	mu        sync.Mutex
+	entries   []soakEntry
+	verifying bool
+}
+
+func newSoakLedger(soak *Soak, logf func(format string, v ...interface{})) *soakLedger {
+	if soak == nil || soak.Verifier == nil {
+		return nil
+	}
+	l := &soakLedger{soak: *soak, logf: logf}
+	if l.soak.Interval <= 0 {
+		l.soak.Interval = 5 * time.Minute
+	}
+	if l.soak.Delay <= 0 {
+		l.soak.Delay = time.Minute
+	}
+	if l.soak.Sample <= 0 {
+		l.soak.Sample = 100
+	}
+	return l
+}
+
+// record adds the datapoints of the update which were sent to the ledger,
+// dropping the oldest entries beyond its capacity.
+func (l *soakLedger) record(u *update, sent func(key string) bool) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, d := range u.ds {
+		key := u.keys[d]
+		if !sent(key) || u.p.quarantine.contains(key) {
+			continue
+		}
+		point := newPoint(d)
+		if point.Time.IsZero() {
+			point.Time = u.now
+		}
+		l.entries = append(l.entries, soakEntry{
+			series: NameWithDimensions(point.Name, point.Dims),
+			value:  point.Value,
+			sent:   point.Time,
+		})
+	}
+	if capacity := 10 * l.soak.Sample; len(l.entries) > capacity {
+		l.entries = append([]soakEntry(nil), l.entries[len(l.entries)-capacity:]...)
+	}
+}
+
+// due removes and returns a sample of the entries sent long enough before
+// now to be verified, oldest first, unless a verification is in progress or
+// none is due.
+func (l *soakLedger) due(now time.Time) ([]soakEntry, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.verifying {
+		return nil, false
+	}
+	n := 0
+	for n < len(l.entries) && n < l.soak.Sample && !l.entries[n].sent.After(now.Add(-l.soak.Delay)) {
+		n++
+	}
+	if n == 0 {
+		return nil, false
+	}
+	due := append([]soakEntry(nil), l.entries[:n]...)
+	l.entries = l.entries[n:]
+	l.verifying = true
+	return due, true
+}
+
+// verify verifies the entries due, reporting the outcome.
+func (l *soakLedger) verify(now time.Time) {
+	entries, ok := l.due(now)
+	if !ok {
+		return
+	}
+	defer func() {
+		l.mu.Lock()
+		l.verifying = false
+		l.mu.Unlock()
+	}()
+	var report SoakReport
+	for _, entry := range entries {
+		report.Verified++
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		values, err := l.soak.Verifier.values(ctx, entry.series, entry.sent.Add(-time.Minute), entry.sent.Add(time.Minute))
+		cancel()
+		switch {
+		case err != nil:
+			report.Errors++
+		case len(values) == 0:
+			report.Missing = append(report.Missing, entry.series)
+		case containsValue(values, entry.value):
+			report.Delivered++
+		default:
+			report.Mismatched = append(report.Mismatched, entry.series)
+		}
+	}
+	sort.Strings(report.Missing)
+	sort.Strings(report.Mismatched)
+	l.logf("%s", report)
+	if l.soak.OnReport != nil {
+		l.soak.OnReport(report)
+	}
+}
+
+// containsValue reports whether the values contain the value, allowing for
+// the rounding of its encoding.
+func containsValue(values []float64, value float64) bool {
+	for _, v := range values {
+		if math.Abs(v-value) <= 1e-9*math.Max(1, math.Abs(value)) {
+			return true
+		}
+	}
+	return false
+}
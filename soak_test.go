@@ -0,0 +1,85 @@
+package signalfx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	metrics "github.com/rcrowley/go-metrics"
+	. "gopkg.in/check.v1"
+)
+
+func (s *Zuite) TestSoak(c *C) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		query := req.URL.Query().Get("query")
+		switch {
+		case strings.Contains(query, `"requests"`):
+			w.Write([]byte(`{"data":{"A":[[1000,3]]}}`))
+		case strings.Contains(query, `"queue"`):
+			w.Write([]byte(`{"data":{"B":[[1000,1]]}}`))
+		case strings.Contains(query, `"errors"`):
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.Write([]byte(`{"data":{}}`))
+		}
+	}))
+	defer server.Close()
+
+	var reports []SoakReport
+	logger, errorLogger := &recordingLogger{}, &recordingLogger{}
+	p := newPublisher("", Options{
+		Soak: &Soak{
+			Verifier: &Verifier{Endpoint: server.URL},
+			Sample:   3,
+			OnReport: func(r SoakReport) { reports = append(reports, r) },
+		},
+		Logger:      logger,
+		ErrorLogger: errorLogger,
+		Summary:     true,
+	})
+	p.sender = &fakeSender{}
+
+	r := metrics.NewRegistry()
+	metrics.GetOrRegisterCounter("requests", r).Inc(3)
+	metrics.GetOrRegisterGauge("queue", r).Update(2)
+	metrics.GetOrRegisterGauge("errors", r).Update(1)
+	metrics.GetOrRegisterGauge("lost[env=prod]", r).Update(1)
+	c.Assert(p.single(r), IsNil)
+	c.Assert(p.soak.entries, HasLen, 4)
+
+	// Values are only verified after the delay.
+	p.soak.verify(time.Now())
+	c.Assert(reports, HasLen, 0)
+
+	later := time.Now().Add(time.Hour)
+	p.soak.verify(later)
+	p.soak.verify(later)
+	c.Assert(reports, HasLen, 2)
+	var total SoakReport
+	for _, report := range reports {
+		total.Verified += report.Verified
+		total.Delivered += report.Delivered
+		total.Errors += report.Errors
+		total.Missing = append(total.Missing, report.Missing...)
+		total.Mismatched = append(total.Mismatched, report.Mismatched...)
+	}
+	c.Assert(total.Verified, Equals, 4)
+	c.Assert(total.Delivered, Equals, 1)
+	c.Assert(total.Errors, Equals, 1)
+	c.Assert(total.Missing, DeepEquals, []string{"lost[env=prod]"})
+	c.Assert(total.Mismatched, DeepEquals, []string{"queue"})
+	c.Assert(reports[0].Verified, Equals, 3)
+	c.Assert(p.soak.entries, HasLen, 0)
+
+	// Reports are logged as information, not as warnings.
+	c.Assert(errorLogger.lines, HasLen, 0)
+	c.Assert(logger.lines[len(logger.lines)-1], Equals, reports[1].String())
+}
+
+func (s *Zuite) TestSoakReport(c *C) {
+	report := SoakReport{Verified: 5, Delivered: 3, Missing: []string{"a"}, Errors: 1}
+	c.Assert(report.Accuracy(), Equals, 0.75)
+	c.Assert(report.String(), Equals,
+		"soak report: accuracy=75.00% verified=5 delivered=3 mismatched=0 missing=1 errors=1 mismatched_series=[] missing_series=[a]")
+}
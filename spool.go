@@ -0,0 +1,267 @@
+package signalfx
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"time"
+
+	"github.com/signalfx/golib/datapoint"
+)
+
+// spoolDrainSize is the maximum number of spooled datapoints resent by a
+// flush, so that draining a long outage does not delay fresh values.
+const spoolDrainSize = 10000
+
+// spool persists the datapoints of failed flushes to a file, one JSON object
+// per line, to resend them with their original timestamps once SignalFX is
+// reachable again, even after a restart. Failed flushes append to the file,
+// and flushes resending datapoints from its head compact it.
+type spool struct {
+	path     string
+	maxBytes int64
+
+	// draining is set while flushes succeed, and taken is the number of
+	// bytes at the head of the file resent by the current flush.
+	draining bool
+	taken    int64
+}
+
+func newSpool(opt Options) *spool {
+	if opt.SpoolPath == "" {
+		return nil
+	}
+	s := &spool{path: opt.SpoolPath, maxBytes: opt.SpoolMaxBytes, draining: true}
+	if s.maxBytes <= 0 {
+		s.maxBytes = 64 << 20
+	}
+	return s
+}
+
+// spooledPoint is the encoding of a datapoint in the spool.
+type spooledPoint struct {
+	Key    string               `json:"key"`
+	Metric string               `json:"metric"`
+	Dims   map[string]string    `json:"dims,omitempty"`
+	Type   datapoint.MetricType `json:"type"`
+	Int    *int64               `json:"int,omitempty"`
+	Float  *float64             `json:"float,omitempty"`
+	Time   int64                `json:"time"`
+}
+
+func newSpooledPoint(key string, d *datapoint.Datapoint) spooledPoint {
+	sp := spooledPoint{
+		Key:    key,
+		Metric: d.Metric,
+		Dims:   d.Dimensions,
+		Type:   d.MetricType,
+		Time:   d.Timestamp.UnixNano() / int64(time.Millisecond),
+	}
+	switch v := d.Value.(type) {
+	case datapoint.IntValue:
+		i := v.Int()
+		sp.Int = &i
+	case datapoint.FloatValue:
+		f := v.Float()
+		sp.Float = &f
+	}
+	return sp
+}
+
+func (sp spooledPoint) datapoint() *datapoint.Datapoint {
+	var value datapoint.Value
+	if sp.Float != nil {
+		value = datapoint.NewFloatValue(*sp.Float)
+	} else if sp.Int != nil {
+		value = datapoint.NewIntValue(*sp.Int)
+	} else {
+		return nil
+	}
+	return datapoint.New(sp.Metric, sp.Dims, value, sp.Type, time.Unix(0, sp.Time*int64(time.Millisecond)))
+}
+
+// take adds the oldest spooled datapoints to the update, ahead of its own,
+// unless the previous flush failed. They stay in the spool until settled.
+// Only the head of the spool is read, for the cost of a flush not to grow
+// with the spool.
+func (s *spool) take(u *update) {
+	if s == nil || !s.draining {
+		return
+	}
+	lines, size, err := s.head(spoolDrainSize)
+	if err != nil {
+		u.p.logf(levelWarning, "WARNING: reading spool %s: %s", s.path, err)
+		return
+	}
+	s.taken = size
+	ds := make([]*datapoint.Datapoint, 0, len(lines))
+	for _, line := range lines {
+		var sp spooledPoint
+		if err := json.Unmarshal(line, &sp); err != nil {
+			u.p.logf(levelVerbose, "skipping corrupt spooled datapoint: %s", err)
+			continue
+		}
+		if d := sp.datapoint(); d != nil {
+			u.keys[d] = sp.Key
+			ds = append(ds, d)
+		}
+	}
+	u.stats.Resent += len(ds)
+	u.ds = append(ds, u.ds...)
+}
+
+// settle removes the datapoints taken by the update from the spool and,
+// if the flush failed, spools those which SignalFX did not acknowledge,
+// timestamped with the time of their collection.
+func (s *spool) settle(u *update, err error) {
+	if s == nil {
+		return
+	}
+	taken := s.taken
+	s.taken = 0
+	s.draining = err == nil
+	if taken != 0 {
+		if cerr := s.compact(taken); cerr != nil {
+			u.p.logf(levelWarning, "WARNING: compacting spool %s: %s", s.path, cerr)
+		}
+	}
+	if err == nil {
+		return
+	}
+	var spooled [][]byte
+	for _, d := range u.ds {
+		key := u.keys[d]
+		if u.acknowledged[key] || u.p.quarantine.contains(key) {
+			continue
+		}
+		if d.Timestamp.IsZero() {
+			d.Timestamp = u.now
+		}
+		line, err := json.Marshal(newSpooledPoint(key, d))
+		if err != nil {
+			continue
+		}
+		spooled = append(spooled, line)
+		u.retained(key)
+	}
+	if len(spooled) == 0 {
+		return
+	}
+	if werr := s.append(u, spooled); werr != nil {
+		u.p.logf(levelWarning, "WARNING: writing spool %s: %s", s.path, werr)
+	}
+}
+
+// head reads up to n datapoints from the head of the spool, all of them if
+// n is negative, and returns them with the number of bytes they take. There
+// are none if the spool does not exist.
+func (s *spool) head(n int) ([][]byte, int64, error) {
+	f, err := os.Open(s.path)
+	if os.IsNotExist(err) {
+		return nil, 0, nil
+	} else if err != nil {
+		return nil, 0, err
+	}
+	defer f.Close()
+	var (
+		lines [][]byte
+		size  int64
+	)
+	r := bufio.NewReader(f)
+	for n < 0 || len(lines) < n {
+		line, err := r.ReadBytes('\n')
+		size += int64(len(line))
+		if line := bytes.TrimSpace(line); len(line) != 0 {
+			lines = append(lines, line)
+		}
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, 0, err
+		}
+	}
+	return lines, size, nil
+}
+
+// compact removes the head of the spool, up to the offset, by copying the
+// rest of it to a new spool replacing it, or removes the spool if nothing
+// is left.
+func (s *spool) compact(offset int64) error {
+	info, err := os.Stat(s.path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if offset >= info.Size() {
+		if err := os.Remove(s.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	f, err := os.Open(s.path)
+	if err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	out, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	_, err = f.Seek(offset, io.SeekStart)
+	if err == nil {
+		_, err = io.Copy(out, f)
+	}
+	if err == nil {
+		err = out.Sync()
+	}
+	f.Close()
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// append appends the lines to the spool, and syncs it. Once the spool
+// reaches its maximum size, the lines are dropped rather than appended.
+func (s *spool) append(u *update, lines [][]byte) error {
+	f, err := os.OpenFile(s.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	size := info.Size()
+	w := bufio.NewWriter(f)
+	for _, line := range lines {
+		if size+int64(len(line))+1 > s.maxBytes {
+			u.stats.BufferDropped++
+			continue
+		}
+		size += int64(len(line)) + 1
+		w.Write(line)
+		w.WriteByte('\n')
+		u.stats.Buffered++
+	}
+	if u.stats.BufferDropped != 0 {
+		u.p.logf(levelWarning, "WARNING: spool %s full, dropped %d datapoints", s.path, u.stats.BufferDropped)
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
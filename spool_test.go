@@ -0,0 +1,92 @@
+package signalfx
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	metrics "github.com/rcrowley/go-metrics"
+	. "gopkg.in/check.v1"
+)
+
+func (s *Zuite) TestSpool_resendsAfterRestart(c *C) {
+	path := filepath.Join(c.MkDir(), "spool")
+	p, sender := newTestPublisher(Options{SpoolPath: path})
+	sender.err = errors.New("unreachable")
+
+	r := metrics.NewRegistry()
+	metrics.GetOrRegisterCounter("requests", r).Inc(3)
+	metrics.GetOrRegisterGaugeFloat64("ratio", r).Update(0.5)
+	c.Assert(p.single(r), ErrorMatches, "unreachable")
+	lines, _, err := p.spool.head(-1)
+	c.Assert(err, IsNil)
+	c.Assert(lines, HasLen, 2)
+
+	// Spooled datapoints are not read back while flushes fail, and the
+	// values collected again, a second later, are spooled too.
+	c.Assert(p.spool.draining, Equals, false)
+	u := p.prepareUpdate()
+	u.now = u.now.Add(time.Second)
+	u.collect(r)
+	c.Assert(u.flush(), ErrorMatches, "unreachable")
+	lines, _, err = p.spool.head(-1)
+	c.Assert(err, IsNil)
+	c.Assert(lines, HasLen, 4)
+
+	// A new publisher resends the spooled datapoints, with their original
+	// timestamps, and empties the spool.
+	p, sender = newTestPublisher(Options{SpoolPath: path})
+	c.Assert(p.single(metrics.NewRegistry()), IsNil)
+	c.Assert(sender.sent, HasLen, 4)
+	batch := newBatch(sender.sent)
+	requests, ok := batch.Get("requests", nil)
+	c.Assert(ok, Equals, true)
+	c.Assert(requests.Value, Equals, 3.0)
+	c.Assert(requests.Type, Equals, PointCounter)
+	c.Assert(requests.Time.IsZero(), Equals, false)
+	ratio, _ := batch.Get("ratio", nil)
+	c.Assert(ratio.Value, Equals, 0.5)
+	_, err = os.Stat(path)
+	c.Assert(os.IsNotExist(err), Equals, true)
+}
+
+func (s *Zuite) TestSpool_maxBytes(c *C) {
+	path := filepath.Join(c.MkDir(), "spool")
+	var stats FlushStats
+	p := newPublisher("", Options{
+		SpoolPath:     path,
+		SpoolMaxBytes: 100,
+		OnFlush:       func(s FlushStats) { stats = s },
+	})
+	p.sender = &fakeSender{err: errors.New("unreachable")}
+
+	r := metrics.NewRegistry()
+	metrics.GetOrRegisterGauge("a", r).Update(1)
+	metrics.GetOrRegisterGauge("b", r).Update(2)
+	c.Assert(p.single(r), ErrorMatches, "unreachable")
+	c.Assert(stats.Buffered, Equals, 1)
+	c.Assert(stats.BufferDropped, Equals, 1)
+}
+
+func (s *Zuite) TestSpool_compact(c *C) {
+	path := filepath.Join(c.MkDir(), "spool")
+	sp := newSpool(Options{SpoolPath: path})
+	c.Assert(ioutil.WriteFile(path, []byte("{\"a\":1}\n{\"b\":2}\n{\"c\":3}\n"), 0600), IsNil)
+
+	lines, size, err := sp.head(2)
+	c.Assert(err, IsNil)
+	c.Assert(lines, HasLen, 2)
+	c.Assert(size, Equals, int64(16))
+
+	// Compacting drops the head, keeping the rest.
+	c.Assert(sp.compact(size), IsNil)
+	lines, _, err = sp.head(-1)
+	c.Assert(err, IsNil)
+	c.Assert(lines, DeepEquals, [][]byte{[]byte(`{"c":3}`)})
+
+	c.Assert(sp.compact(8), IsNil)
+	_, err = os.Stat(path)
+	c.Assert(os.IsNotExist(err), Equals, true)
+}
@@ -0,0 +1,221 @@
+package signalfx
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	metrics "github.com/rcrowley/go-metrics"
+)
+
+// FlushStats describes the outcome of a single flush.
+type FlushStats struct {
+	// Emitted is the number of datapoints successfully sent.
+	Emitted int
+
+	// Suppressed is the number of datapoints not sent because their value
+	// did not change since it was last sent.
+	Suppressed int
+
+	// Late is the number of datapoints dropped for being older than
+	// Options.MaxDatapointAge.
+	Late int
+
+	// Duplicates is the number of datapoints dropped for having the same
+	// name, dimensions and timestamp as another datapoint of the flush,
+	// when datapoints of failed flushes are resent.
+	Duplicates int
+
+	// Shed is the number of datapoints dropped, lowest priority first, to
+	// stay within Options.DPMBudget or Options.MaxDatapointsPerMinute, or
+	// while probing a half-open circuit.
+	Shed int
+
+	// Buffered is the number of datapoints retained after the flush
+	// failed, Resent the number of datapoints of failed flushes sent again,
+	// and BufferDropped the number of datapoints dropped from the full
+	// buffer or spool, see Options.BufferSize and Options.SpoolPath.
+	Buffered      int
+	Resent        int
+	BufferDropped int
+
+	// Rejected is the number of datapoints SignalFX rejected as invalid,
+	// and RejectReasons counts them by the reason SignalFX gave.
+	Rejected      int
+	RejectReasons map[string]int
+
+	// Invalid is the number of datapoints dropped or fixed because they
+	// failed validation.
+	Invalid int
+
+	// Overflowed is the number of dimension values replaced because their
+	// dimension has too many values.
+	Overflowed int
+
+	// RolledUp is the number of dimensions hashed or dropped because they
+	// were rolled up.
+	RolledUp int
+
+	// Slow is the number of metrics not collected because collecting the
+	// metrics was slow, see Options.SlowCollection.
+	Slow int
+
+	// Quarantined is the number of datapoints not sent because SignalFX
+	// previously rejected their series.
+	Quarantined int
+
+	// Batches is the number of requests made to SignalFX.
+	Batches int
+
+	// Registries describes the registries published, the registry of the
+	// publisher first, then Options.Registries, and then the registry of the
+	// RuntimeMetrics, if any.
+	Registries []RegistryStats
+
+	// Bytes is the size of the payloads sent to SignalFX.
+	Bytes int64
+
+	// TotalBytes is the cumulative size of the payloads sent to SignalFX by
+	// the publisher, quantifying its network egress.
+	TotalBytes int64
+
+	// Latency is the duration of the flush, from collection to the end of
+	// the last request.
+	Latency time.Duration
+
+	// AllocBytes and Allocs are the bytes and objects allocated during the
+	// flush, and CPU the CPU time used by the process, when
+	// Options.Profile is set. They include the activity of other
+	// goroutines, and are therefore upper bounds of the cost of the flush.
+	AllocBytes int64
+	Allocs     int64
+	CPU        time.Duration
+
+	// Err is the error which caused the flush to fail, if any.
+	Err error
+}
+
+// SuppressionRatio returns the fraction of the datapoints not sent because
+// their value did not change.
+func (s FlushStats) SuppressionRatio() float64 {
+	return ratio(s.Suppressed, s.Emitted+s.Suppressed)
+}
+
+// AverageBatchSize returns the average number of datapoints sent per
+// request.
+func (s FlushStats) AverageBatchSize() float64 {
+	return ratio(s.Emitted, s.Batches)
+}
+
+func ratio(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(n) / float64(total)
+}
+
+// RegistryStats describes the metrics of a registry published.
+type RegistryStats struct {
+	// Metrics is the number of metrics of the registry, by type: "counter",
+	// "gauge", "gauge_float64", "histogram", "meter" or "timer".
+	Metrics map[string]int
+}
+
+// metricType returns the type of the metric, as in RegistryStats.
+func metricType(i interface{}) string {
+	switch i.(type) {
+	case metrics.Counter:
+		return "counter"
+	case metrics.Gauge:
+		return "gauge"
+	case metrics.GaugeFloat64:
+		return "gauge_float64"
+	case metrics.Histogram:
+		return "histogram"
+	case metrics.Meter:
+		return "meter"
+	case metrics.Timer:
+		return "timer"
+	}
+	return "unknown"
+}
+
+// count counts the metric of the registry being converted.
+func (u *update) count(i interface{}) {
+	if u.source.registry >= len(u.stats.Registries) {
+		return
+	}
+	r := &u.stats.Registries[u.source.registry]
+	if r.Metrics == nil {
+		r.Metrics = make(map[string]int)
+	}
+	r.Metrics[metricType(i)]++
+}
+
+// Stats are the statistics of a publisher since it was created, see
+// Publisher.Stats.
+type Stats struct {
+	// Flushes is the number of flushes, and Errors the number of those
+	// which failed.
+	Flushes int
+	Errors  int
+
+	// Emitted, Suppressed, Batches and Bytes are the totals of those of
+	// the flushes.
+	Emitted    int
+	Suppressed int
+	Batches    int
+	Bytes      int64
+
+	// Last is the stats of the last flush, describing the last interval.
+	Last FlushStats
+}
+
+// SuppressionRatio returns the fraction of the datapoints not sent because
+// their value did not change.
+func (s Stats) SuppressionRatio() float64 {
+	return ratio(s.Suppressed, s.Emitted+s.Suppressed)
+}
+
+// AverageBatchSize returns the average number of datapoints sent per
+// request.
+func (s Stats) AverageBatchSize() float64 {
+	return ratio(s.Emitted, s.Batches)
+}
+
+// statsRecorder accumulates the stats of the flushes, for any goroutine to
+// read.
+type statsRecorder struct {
+	mu    sync.Mutex
+	stats Stats
+}
+
+func (r *statsRecorder) record(stats FlushStats) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stats.Flushes++
+	if stats.Err != nil {
+		r.stats.Errors++
+	}
+	r.stats.Emitted += stats.Emitted
+	r.stats.Suppressed += stats.Suppressed
+	r.stats.Batches += stats.Batches
+	r.stats.Bytes += stats.Bytes
+	r.stats.Last = stats
+}
+
+func (r *statsRecorder) get() Stats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.stats
+}
+
+// String formats the stats as a structured summary line.
+func (s FlushStats) String() string {
+	outcome := "ok"
+	if s.Err != nil {
+		outcome = fmt.Sprintf("%q", s.Err.Error())
+	}
+	return fmt.Sprintf("flush summary: emitted=%d suppressed=%d late=%d rejected=%d batches=%d bytes=%d latency=%s outcome=%s",
+		s.Emitted, s.Suppressed, s.Late, s.Rejected, s.Batches, s.Bytes, s.Latency, outcome)
+}
@@ -0,0 +1,57 @@
+package signalfx
+
+import (
+	"errors"
+	"time"
+
+	metrics "github.com/rcrowley/go-metrics"
+	. "gopkg.in/check.v1"
+)
+
+func (s *Zuite) TestFlushStats_String(c *C) {
+	stats := FlushStats{Emitted: 3, Suppressed: 7, Batches: 1, Bytes: 120, Latency: 15 * time.Millisecond}
+	c.Assert(stats.String(), Equals, "flush summary: emitted=3 suppressed=7 late=0 rejected=0 batches=1 bytes=120 latency=15ms outcome=ok")
+
+	stats = FlushStats{Batches: 1, Err: errors.New("timeout")}
+	c.Assert(stats.String(), Equals, `flush summary: emitted=0 suppressed=0 late=0 rejected=0 batches=1 bytes=0 latency=0s outcome="timeout"`)
+}
+
+func (s *Zuite) TestAppendIfGaugeChanged_suppressedStats(c *C) {
+	p := newPublisher("", Options{})
+	p.last.gauges["same"] = 5
+
+	u := p.prepareUpdate()
+	u.appendIfGaugeChanged("same", 5)
+	u.appendIfGaugeChanged("different", 5)
+
+	c.Assert(u.stats.Suppressed, Equals, 1)
+}
+
+func (s *Zuite) TestPublisher_Stats(c *C) {
+	sender := &fakeSender{}
+	pub := New(metrics.NewRegistry(), "")
+	pub.p.sender = sender
+	pub.p.opt.Registries = []metrics.Registry{metrics.NewRegistry()}
+
+	metrics.GetOrRegisterCounter("requests", pub.r).Inc(1)
+	metrics.GetOrRegisterGauge("queue", pub.r).Update(1)
+	metrics.GetOrRegisterTimer("latency", pub.p.opt.Registries[0]).Update(time.Millisecond)
+	c.Assert(pub.p.single(pub.r), IsNil)
+	c.Assert(pub.p.single(pub.r), IsNil)
+	sender.err = errors.New("unreachable")
+	metrics.GetOrRegisterCounter("requests", pub.r).Inc(1)
+	c.Assert(pub.p.single(pub.r), NotNil)
+
+	stats := pub.Stats()
+	c.Assert(stats.Flushes, Equals, 3)
+	c.Assert(stats.Errors, Equals, 1)
+	c.Assert(stats.Emitted, Equals, 16)
+	c.Assert(stats.Suppressed, Equals, 16+15)
+	c.Assert(stats.Batches, Equals, 2)
+	c.Assert(stats.AverageBatchSize(), Equals, 8.0)
+	c.Assert(stats.Last.Registries, DeepEquals, []RegistryStats{
+		{Metrics: map[string]int{"counter": 1, "gauge": 1}},
+		{Metrics: map[string]int{"timer": 1}},
+	})
+	c.Assert(stats.Last.SuppressionRatio(), Equals, 1.0)
+}
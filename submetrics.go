@@ -0,0 +1,122 @@
+package signalfx
+
+// ShortSubmetricNames are SubmetricNames shortening the suffixes of
+// submetrics, e.g. "p99" rather than "99-percentile" and "rate.1m" rather
+// than "one-minute".
+var ShortSubmetricNames = map[string]string{
+	"std-dev":        "stddev",
+	"50-percentile":  "p50",
+	"75-percentile":  "p75",
+	"90-percentile":  "p90",
+	"95-percentile":  "p95",
+	"99-percentile":  "p99",
+	"999-percentile": "p999",
+	"one-minute":     "rate.1m",
+	"five-minute":    "rate.5m",
+	"fifteen-minute": "rate.15m",
+	"mean-rate":      "rate.mean",
+}
+
+// submetricKinds are the kinds of submetrics, by suffix, submetrics of
+// other suffixes being percentiles, see datapointKind.
+var submetricKinds = map[string]int{
+	"count":          kindCounter,
+	"sum":            kindCounter,
+	"min":            kindGauge,
+	"max":            kindGauge,
+	"mean":           kindGauge,
+	"std-dev":        kindGauge,
+	"one-minute":     kindRate,
+	"five-minute":    kindRate,
+	"fifteen-minute": kindRate,
+	"mean-rate":      kindRate,
+}
+
+// submetrics appends the submetrics of a histogram, meter or timer, named
+// after the metric with the suffix of each, unless dropped, see
+// Options.DropSubmetrics and Options.SubmetricNames.
+type submetrics struct {
+	u    *update
+	base string
+	drop map[string]bool
+}
+
+func (u *update) submetrics(name, metricType string) submetrics {
+	return submetrics{u: u, base: name, drop: u.p.dropSubmetrics[metricType]}
+}
+
+// name returns the name of the submetric with the suffix, and whether it is
+// sent.
+func (s submetrics) name(suffix string) (string, bool) {
+	if s.drop[suffix] {
+		return "", false
+	}
+	if renamed, ok := s.u.p.opt.SubmetricNames[suffix]; ok {
+		suffix = renamed
+	}
+	return s.base + "." + suffix, true
+}
+
+// tag tags the datapoints appended by f with the kind of the submetric of
+// the suffix, whichever name it is sent as.
+func (s submetrics) tag(suffix string, f func()) {
+	kind, ok := submetricKinds[suffix]
+	if !ok {
+		kind = kindPercentile
+	}
+	n := len(s.u.ds)
+	f()
+	for _, d := range s.u.ds[n:] {
+		tagKind(d, kind)
+	}
+}
+
+func (s submetrics) count(suffix string, count int64) {
+	if name, ok := s.name(suffix); ok {
+		s.tag(suffix, func() { s.u.appendIfCountChanged(name, count) })
+	}
+}
+
+func (s submetrics) events(suffix string, count int64) {
+	if name, ok := s.name(suffix); ok {
+		s.tag(suffix, func() { s.u.appendIfEventsChanged(name, count) })
+	}
+}
+
+func (s submetrics) counter(suffix string, counter int64) {
+	if name, ok := s.name(suffix); ok {
+		s.tag(suffix, func() { s.u.appendIfCounterChanged(name, counter) })
+	}
+}
+
+func (s submetrics) gaugeF(suffix string, gaugeF float64) {
+	if name, ok := s.name(suffix); ok {
+		s.tag(suffix, func() { s.u.appendIfGaugeFChanged(name, gaugeF) })
+	}
+}
+
+// duration appends a duration in nanoseconds as a counter or, scaled to
+// another unit, as a float, so that e.g. 400µs is 0.4ms rather than 0.
+func (s submetrics) duration(suffix string, ns int64, scale float64) {
+	if scale == 1 {
+		s.counter(suffix, ns)
+		return
+	}
+	s.gaugeF(suffix, float64(ns)/scale)
+}
+
+// newDropSubmetrics indexes the submetrics dropped, by metric type and
+// suffix, or returns nil if none is.
+func newDropSubmetrics(drop map[string][]string) map[string]map[string]bool {
+	if len(drop) == 0 {
+		return nil
+	}
+	index := make(map[string]map[string]bool, len(drop))
+	for metricType, suffixes := range drop {
+		index[metricType] = make(map[string]bool, len(suffixes))
+		for _, suffix := range suffixes {
+			index[metricType][suffix] = true
+		}
+	}
+	return index
+}
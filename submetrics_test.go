@@ -0,0 +1,67 @@
+package signalfx
+
+import (
+	"time"
+
+	metrics "github.com/rcrowley/go-metrics"
+	. "gopkg.in/check.v1"
+)
+
+func (s *Zuite) TestDropSubmetrics(c *C) {
+	r := metrics.NewRegistry()
+	metrics.GetOrRegisterTimer("latency", r).Update(1)
+	metrics.GetOrRegisterHistogram("sizes", r, metrics.NewUniformSample(10)).Update(1)
+
+	batch := Collect(r, Options{DropSubmetrics: map[string][]string{
+		"timer": {"std-dev", "min", "max", "50-percentile", "75-percentile", "95-percentile",
+			"one-minute", "five-minute", "fifteen-minute"},
+		"histogram": {"std-dev"},
+	}})
+	c.Assert(batchNames(batch), DeepEquals, []string{
+		"latency.99-percentile", "latency.999-percentile", "latency.count", "latency.mean", "latency.mean-rate",
+		"sizes.50-percentile", "sizes.75-percentile", "sizes.95-percentile", "sizes.99-percentile", "sizes.999-percentile",
+		"sizes.count", "sizes.max", "sizes.mean", "sizes.min",
+	})
+}
+
+func (s *Zuite) TestSubmetricNames(c *C) {
+	r := metrics.NewRegistry()
+	metrics.GetOrRegisterTimer("latency", r).Update(1)
+
+	batch := Collect(r, Options{
+		SubmetricNames: ShortSubmetricNames,
+		DropSubmetrics: map[string][]string{"timer": {"99-percentile"}},
+	})
+	c.Assert(batchNames(batch), DeepEquals, []string{
+		"latency.count", "latency.max", "latency.mean", "latency.min", "latency.p50", "latency.p75", "latency.p95",
+		"latency.p999", "latency.rate.15m", "latency.rate.1m", "latency.rate.5m", "latency.rate.mean", "latency.stddev",
+	})
+}
+
+func (s *Zuite) TestSum(c *C) {
+	r := metrics.NewRegistry()
+	sizes := NewSummedHistogram(metrics.NewUniformSample(10))
+	r.Register("sizes", sizes)
+	for i := 1; i <= 100; i++ {
+		sizes.Update(int64(i))
+	}
+	latency := NewSummedTimer()
+	r.Register("latency", latency)
+	latency.Update(2 * time.Millisecond)
+	latency.Update(5 * time.Millisecond)
+	metrics.GetOrRegisterHistogram("plain", r, metrics.NewUniformSample(10)).Update(1)
+
+	// The sum covers all the values, beyond the 10 of the sample.
+	batch := Collect(r, Options{Units: map[string]Unit{"latency": UnitMilliseconds}})
+	point, _ := batch.Get("sizes.sum", nil)
+	c.Assert(point.Value, Equals, 5050.0)
+	point, _ = batch.Get("sizes.count", nil)
+	c.Assert(point.Value, Equals, 100.0)
+	point, _ = batch.Get("latency.sum", map[string]string{"unit": "ms"})
+	c.Assert(point.Value, Equals, 7.0)
+	_, ok := batch.Get("plain.sum", nil)
+	c.Assert(ok, Equals, false)
+
+	sizes.Clear()
+	c.Assert(sizes.RunningSum(), Equals, int64(0))
+}
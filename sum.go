@@ -0,0 +1,85 @@
+package signalfx
+
+import (
+	"sync/atomic"
+	"time"
+
+	metrics "github.com/rcrowley/go-metrics"
+)
+
+// runningSum is implemented by the histograms and timers which keep the sum
+// of all their values, sent as their ".sum" submetric. The Sum of other
+// histograms and timers only covers their sample, and is not sent.
+type runningSum interface {
+	RunningSum() int64
+}
+
+// SummedHistogram is a histogram keeping the sum of all its values, sent as
+// its ".sum" submetric, e.g. to compute averages across hosts from the sums
+// and counts. Sum is the sum of the values in the sample.
+type SummedHistogram struct {
+	metrics.Histogram
+	sum int64
+}
+
+// NewSummedHistogram creates a SummedHistogram with the sample, to be
+// registered, e.g.
+//
+// 	r.Register("sizes", signalfx.NewSummedHistogram(metrics.NewUniformSample(1028)))
+func NewSummedHistogram(s metrics.Sample) *SummedHistogram {
+	return &SummedHistogram{Histogram: metrics.NewHistogram(s)}
+}
+
+// Update records the value.
+func (h *SummedHistogram) Update(v int64) {
+	h.Histogram.Update(v)
+	atomic.AddInt64(&h.sum, v)
+}
+
+// Clear clears the histogram, and its sum.
+func (h *SummedHistogram) Clear() {
+	h.Histogram.Clear()
+	atomic.StoreInt64(&h.sum, 0)
+}
+
+// RunningSum returns the sum of all the values recorded since the
+// histogram was created or cleared.
+func (h *SummedHistogram) RunningSum() int64 {
+	return atomic.LoadInt64(&h.sum)
+}
+
+// SummedTimer is a timer keeping the sum of all its durations, sent as its
+// ".sum" submetric, see SummedHistogram.
+type SummedTimer struct {
+	metrics.Timer
+	sum int64
+}
+
+// NewSummedTimer creates a SummedTimer, to be registered.
+func NewSummedTimer() *SummedTimer {
+	return &SummedTimer{Timer: metrics.NewTimer()}
+}
+
+// Time records the duration of the execution of f.
+func (t *SummedTimer) Time(f func()) {
+	ts := time.Now()
+	f()
+	t.Update(time.Since(ts))
+}
+
+// Update records the duration.
+func (t *SummedTimer) Update(d time.Duration) {
+	t.Timer.Update(d)
+	atomic.AddInt64(&t.sum, int64(d))
+}
+
+// UpdateSince records the duration since ts.
+func (t *SummedTimer) UpdateSince(ts time.Time) {
+	t.Update(time.Since(ts))
+}
+
+// RunningSum returns the sum of all the durations recorded since the timer
+// was created, in nanoseconds.
+func (t *SummedTimer) RunningSum() int64 {
+	return atomic.LoadInt64(&t.sum)
+}
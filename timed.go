@@ -0,0 +1,67 @@
+package signalfx
+
+import (
+	"time"
+
+	metrics "github.com/rcrowley/go-metrics"
+)
+
+// Timed runs f, recording its duration in the timer name, and counting its
+// executions in the counters name.success and name.failure, depending on
+// whether it returned an error or panicked, all with the dimensions. It
+// returns the error of f:
+//
+// 	err := signalfx.Timed(metrics.DefaultRegistry, "jobs.reindex", map[string]string{
+// 		"shard": shard,
+// 	}, reindex)
+func Timed(r metrics.Registry, name string, dims map[string]string, f func() error) (err error) {
+	s := StartStopwatch(r, name, dims)
+	panicked := true
+	defer func() {
+		if panicked {
+			s.Fail()
+		}
+	}()
+	err = f()
+	panicked = false
+	s.Stop(err)
+	return err
+}
+
+// Stopwatch times an execution, recording it as Timed does, for executions
+// which are not wrapped in a function, e.g. handlers:
+//
+// 	s := signalfx.StartStopwatch(metrics.DefaultRegistry, "checkout", nil)
+// 	defer func() { s.Stop(err) }()
+type Stopwatch struct {
+	r     metrics.Registry
+	name  string
+	dims  map[string]string
+	start time.Time
+}
+
+// StartStopwatch starts timing an execution.
+func StartStopwatch(r metrics.Registry, name string, dims map[string]string) *Stopwatch {
+	return &Stopwatch{r: r, name: name, dims: dims, start: time.Now()}
+}
+
+// Stop records the execution, failed if err is not nil, and returns its
+// duration.
+func (s *Stopwatch) Stop(err error) time.Duration {
+	if err != nil {
+		return s.Fail()
+	}
+	return s.record(".success")
+}
+
+// Fail records the execution as failed, and returns its duration.
+func (s *Stopwatch) Fail() time.Duration {
+	return s.record(".failure")
+}
+
+func (s *Stopwatch) record(outcome string) time.Duration {
+	elapsed := time.Since(s.start)
+	metrics.GetOrRegisterTimer(NameWithDimensions(s.name, s.dims), s.r).Update(elapsed)
+	metrics.GetOrRegisterCounter(NameWithDimensions(s.name+outcome, s.dims), s.r).Inc(1)
+	return elapsed
+}
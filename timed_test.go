@@ -0,0 +1,34 @@
+package signalfx
+
+import (
+	"errors"
+
+	metrics "github.com/rcrowley/go-metrics"
+	. "gopkg.in/check.v1"
+)
+
+func (s *Zuite) TestTimed(c *C) {
+	r := metrics.NewRegistry()
+	dims := map[string]string{"shard": "1"}
+	c.Assert(Timed(r, "jobs", dims, func() error { return nil }), IsNil)
+	c.Assert(Timed(r, "jobs", dims, func() error { return errors.New("failed") }), ErrorMatches, "failed")
+	c.Assert(func() {
+		Timed(r, "jobs", dims, func() error { panic("boom") })
+	}, PanicMatches, "boom")
+
+	batch := Collect(r, Options{})
+	count, _ := batch.Get("jobs.count", dims)
+	c.Assert(count.Value, Equals, 3.0)
+	success, _ := batch.Get("jobs.success", dims)
+	c.Assert(success.Value, Equals, 1.0)
+	failure, _ := batch.Get("jobs.failure", dims)
+	c.Assert(failure.Value, Equals, 2.0)
+}
+
+func (s *Zuite) TestStopwatch(c *C) {
+	r := metrics.NewRegistry()
+	sw := StartStopwatch(r, "checkout", nil)
+	c.Assert(sw.Stop(nil) > 0, Equals, true)
+	c.Assert(metrics.GetOrRegisterCounter("checkout.success", r).Count(), Equals, int64(1))
+	c.Assert(metrics.GetOrRegisterTimer("checkout", r).Count(), Equals, int64(1))
+}
@@ -0,0 +1,94 @@
+package signalfx
+
+import (
+	"time"
+
+	"github.com/signalfx/golib/datapoint"
+)
+
+// TimestampMode controls the timestamps carried by datapoints.
+type TimestampMode int
+
+const (
+	// TimestampNone sends datapoints without timestamps, SignalFX assigning
+	// the time of reception.
+	TimestampNone TimestampMode = iota
+
+	// TimestampCollection timestamps datapoints with the time at which the
+	// registry was collected.
+	TimestampCollection
+
+	// TimestampCustom timestamps datapoints with Options.TimestampFunc.
+	TimestampCustom
+)
+
+// timestamp returns the timestamp of datapoints collected at the given time,
+// or the zero time if datapoints carry no timestamps.
+func (p *publisher) timestamp(collected time.Time) time.Time {
+	var timestamp time.Time
+	switch p.opt.Timestamps {
+	case TimestampCollection:
+		timestamp = collected
+	case TimestampCustom:
+		if p.opt.TimestampFunc != nil {
+			timestamp = p.opt.TimestampFunc(collected)
+		}
+	}
+	if !timestamp.IsZero() && p.opt.CorrectClockSkew {
+		if skew, ok := p.transport.clockSkew(); ok {
+			timestamp = timestamp.Add(skew)
+		}
+	}
+	return timestamp
+}
+
+// checkClockSkew warns when the clock skew exceeds the maximum, and when it
+// goes back within the maximum.
+func (p *publisher) checkClockSkew() {
+	if p.opt.MaxClockSkew <= 0 {
+		return
+	}
+	skew, ok := p.transport.clockSkew()
+	if !ok {
+		return
+	}
+	skewed := skew > p.opt.MaxClockSkew || skew < -p.opt.MaxClockSkew
+	if skewed && !p.skewed {
+		p.logf(levelWarning, "WARNING: local clock is skewed by %s from SignalFX's, exceeding %s", -skew, p.opt.MaxClockSkew)
+	} else if !skewed && p.skewed {
+		p.logf(levelWarning, "local clock skew of %s is back within %s", -skew, p.opt.MaxClockSkew)
+	}
+	p.skewed = skewed
+}
+
+// append adds the datapoint of the series identified by key to the update,
+// timestamping it as configured. It returns whether the datapoint passed
+// validation and was added.
+func (u *update) append(key string, d *datapoint.Datapoint) bool {
+	if !u.validate(key, d) {
+		return false
+	}
+	if !u.timestamp.IsZero() {
+		d.Timestamp = u.timestamp
+	}
+	u.ds = append(u.ds, d)
+	u.keys[d] = key
+	return true
+}
+
+// dropLate removes the datapoints older than the maximum age, returning the
+// remaining datapoints and the number of datapoints dropped. Datapoints
+// without timestamps are never dropped.
+func (p *publisher) dropLate(ds []*datapoint.Datapoint, now time.Time) ([]*datapoint.Datapoint, int) {
+	if p.opt.MaxDatapointAge <= 0 {
+		return ds, 0
+	}
+	oldest := now.Add(-p.opt.MaxDatapointAge)
+	kept := ds[:0]
+	for _, d := range ds {
+		if d.Timestamp.IsZero() || !d.Timestamp.Before(oldest) {
+			kept = append(kept, d)
+		}
+	}
+	return kept, len(ds) - len(kept)
+}
@@ -0,0 +1,89 @@
+package signalfx
+
+import (
+	"time"
+
+	"github.com/signalfx/golib/datapoint"
+	"github.com/signalfx/golib/sfxclient"
+	. "gopkg.in/check.v1"
+)
+
+func (s *Zuite) TestTimestamp(c *C) {
+	collected := time.Date(2017, 3, 1, 10, 20, 37, 0, time.UTC)
+	truncate := func(t time.Time) time.Time { return t.Truncate(15 * time.Second) }
+
+	for _, example := range []struct {
+		opt      Options
+		expected time.Time
+	}{
+		{Options{}, time.Time{}},
+		{Options{Timestamps: TimestampCollection}, collected},
+		{Options{Timestamps: TimestampCustom, TimestampFunc: truncate}, time.Date(2017, 3, 1, 10, 20, 30, 0, time.UTC)},
+		{Options{Timestamps: TimestampCustom}, time.Time{}},
+	} {
+		p := newPublisher("", example.opt)
+		c.Assert(p.timestamp(collected), Equals, example.expected)
+	}
+}
+
+func (s *Zuite) TestAppend_timestamps(c *C) {
+	p := newPublisher("", Options{Timestamps: TimestampCollection})
+
+	u := p.prepareUpdate()
+	u.appendIfCounterChanged("requests", 1)
+	u.appendIfGaugeFChanged("load", 0.5)
+
+	c.Assert(u.ds, HasLen, 2)
+	c.Assert(u.ds[0].Timestamp.IsZero(), Equals, false)
+	c.Assert(u.ds[1].Timestamp, Equals, u.ds[0].Timestamp)
+}
+
+func (s *Zuite) TestDropLate(c *C) {
+	now := time.Date(2017, 3, 1, 10, 20, 0, 0, time.UTC)
+	p := newPublisher("", Options{MaxDatapointAge: time.Hour})
+
+	ds := []*datapoint.Datapoint{
+		sfxclient.Gauge("untimestamped", nil, 1),
+		sfxclient.Gauge("recent", nil, 2),
+		sfxclient.Gauge("old", nil, 3),
+	}
+	ds[1].Timestamp = now.Add(-59 * time.Minute)
+	ds[2].Timestamp = now.Add(-61 * time.Minute)
+
+	kept, dropped := p.dropLate(ds, now)
+
+	c.Assert(dropped, Equals, 1)
+	c.Assert(kept, HasLen, 2)
+	c.Assert(kept[0].Metric, Equals, "untimestamped")
+	c.Assert(kept[1].Metric, Equals, "recent")
+}
+
+func (s *Zuite) TestTimestamp_correctClockSkew(c *C) {
+	collected := time.Date(2017, 3, 1, 10, 20, 0, 0, time.UTC)
+	p := newPublisher("", Options{Timestamps: TimestampCollection, CorrectClockSkew: true})
+	p.transport.observeDate(collected.Add(-10*time.Second), collected, collected)
+
+	c.Assert(p.timestamp(collected), Equals, collected.Add(-9500*time.Millisecond))
+}
+
+func (s *Zuite) TestCheckClockSkew(c *C) {
+	now := time.Date(2017, 3, 1, 10, 20, 0, 0, time.UTC)
+	logger := &recordingLogger{}
+	p := newPublisher("", Options{Logger: logger, MaxClockSkew: 5 * time.Second})
+
+	p.checkClockSkew()
+	p.transport.observeDate(now.Add(-3*time.Second), now, now)
+	p.checkClockSkew()
+	c.Assert(logger.lines, HasLen, 0)
+
+	p.transport.observeDate(now.Add(-30*time.Second), now, now)
+	p.checkClockSkew()
+	p.checkClockSkew()
+	p.transport.observeDate(now, now, now)
+	p.checkClockSkew()
+
+	c.Assert(logger.lines, DeepEquals, []string{
+		"WARNING: local clock is skewed by 29.5s from SignalFX's, exceeding 5s",
+		"local clock skew of -500ms is back within 5s",
+	})
+}
@@ -0,0 +1,49 @@
+package signalfx
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/signalfx/golib/event"
+	"github.com/signalfx/golib/sfxclient"
+)
+
+// TokenEventType is the type of the events sent when the publisher fails
+// over to the other token, see SecondaryToken.
+const TokenEventType = "signalfx.token"
+
+// tokenNames name the tokens in logs and events.
+var tokenNames = [2]string{"primary", "secondary"}
+
+// tokenRejected reports whether SignalFX rejected the token used to send.
+func tokenRejected(err error) bool {
+	apiErr, ok := err.(sfxclient.SFXAPIError)
+	if !ok {
+		return false
+	}
+	return apiErr.StatusCode == http.StatusUnauthorized || apiErr.StatusCode == http.StatusForbidden
+}
+
+// failoverToken switches to the other token when SignalFX rejected the
+// active one and a secondary token is set. It logs the switch, and records it
+// with an event sent using the newly active token.
+func (p *publisher) failoverToken(err error) {
+	if p.opt.SecondaryToken == "" || p.client == nil || !tokenRejected(err) {
+		return
+	}
+	from := p.activeToken
+	p.activeToken = 1 - from
+	p.authToken = p.tokens[p.activeToken]
+	p.client.AuthToken = p.authToken
+	p.logf(levelWarning, "WARNING: %s token rejected (%s), switching to the %s token",
+		tokenNames[from], err, tokenNames[p.activeToken])
+
+	e := event.NewWithProperties(TokenEventType, event.USERDEFINED, p.defaultDims, map[string]interface{}{
+		"token":    tokenNames[p.activeToken],
+		"rejected": tokenNames[from],
+	}, time.Now())
+	if err := p.sender.AddEvents(context.Background(), []*event.Event{e}); err != nil {
+		p.logf(levelWarning, "WARNING: unable to send %s event: %s", TokenEventType, err)
+	}
+}
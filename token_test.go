@@ -0,0 +1,66 @@
+package signalfx
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/signalfx/golib/event"
+	"github.com/signalfx/golib/sfxclient"
+	. "gopkg.in/check.v1"
+)
+
+// eventSender records the events sent.
+type eventSender struct {
+	fakeSender
+	events []*event.Event
+}
+
+func (e *eventSender) AddEvents(_ context.Context, events []*event.Event) error {
+	e.events = append(e.events, events...)
+	return nil
+}
+
+func (s *Zuite) TestTokenRejected(c *C) {
+	c.Assert(tokenRejected(sfxclient.SFXAPIError{StatusCode: http.StatusUnauthorized}), Equals, true)
+	c.Assert(tokenRejected(sfxclient.SFXAPIError{StatusCode: http.StatusForbidden}), Equals, true)
+	c.Assert(tokenRejected(sfxclient.SFXAPIError{StatusCode: http.StatusBadRequest}), Equals, false)
+	c.Assert(tokenRejected(errors.New("forbidden")), Equals, false)
+	c.Assert(tokenRejected(nil), Equals, false)
+}
+
+func (s *Zuite) TestFailoverToken(c *C) {
+	logger := &recordingLogger{}
+	p := newPublisher("primary-token", Options{SecondaryToken: "secondary-token", Logger: logger})
+	sender := &eventSender{}
+	p.client = sfxclient.NewHTTPSink()
+	p.client.AuthToken = p.authToken
+	p.sender = sender
+
+	// Other errors are not caused by the token.
+	p.failoverToken(sfxclient.SFXAPIError{StatusCode: http.StatusBadRequest})
+	c.Assert(p.authToken, Equals, "primary-token")
+
+	p.failoverToken(sfxclient.SFXAPIError{StatusCode: http.StatusUnauthorized})
+	c.Assert(p.authToken, Equals, "secondary-token")
+	c.Assert(p.client.AuthToken, Equals, "secondary-token")
+	c.Assert(sender.events, HasLen, 1)
+	c.Assert(sender.events[0].EventType, Equals, TokenEventType)
+	c.Assert(sender.events[0].Properties["token"], Equals, "secondary")
+	c.Assert(logger.lines, HasLen, 1)
+	c.Assert(logger.lines[0], Matches, "WARNING: primary token rejected .*, switching to the secondary token")
+
+	// Back to the primary token, once the secondary is rejected in turn.
+	p.failoverToken(sfxclient.SFXAPIError{StatusCode: http.StatusForbidden})
+	c.Assert(p.authToken, Equals, "primary-token")
+	c.Assert(sender.events, HasLen, 2)
+}
+
+func (s *Zuite) TestFailoverToken_noSecondary(c *C) {
+	p := newPublisher("primary-token", Options{})
+	p.client = sfxclient.NewHTTPSink()
+	p.sender = &eventSender{}
+
+	p.failoverToken(sfxclient.SFXAPIError{StatusCode: http.StatusUnauthorized})
+	c.Assert(p.authToken, Equals, "primary-token")
+}
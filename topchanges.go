@@ -0,0 +1,77 @@
+package signalfx
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// defaultTopChanges is the number of changes reported in verbose mode when
+// Options.TopChanges is not set.
+const defaultTopChanges = 10
+
+// change is a series whose value is flushed, with its last flushed value.
+type change struct {
+	series    string
+	last      float64
+	value     float64
+	added     bool
+	magnitude float64
+}
+
+// logChanges reports, in verbose mode, how many series of each kind are
+// flushed, and the largest changes of value. New series come first, as their
+// change is from nothing.
+func (u *update) logChanges() {
+	if !u.p.enabled(levelVerbose) {
+		return
+	}
+	var changes []change
+	add := func(series string, value float64, last float64, ok bool) {
+		c := change{series: series, last: last, value: value, added: !ok}
+		if ok {
+			c.magnitude = math.Abs(value - last)
+		}
+		changes = append(changes, c)
+	}
+	for series, value := range u.changes.counters {
+		last, ok := u.p.last.counters[series]
+		add(series, float64(value), float64(last), ok)
+	}
+	for series, value := range u.changes.gauges {
+		last, ok := u.p.last.gauges[series]
+		add(series, float64(value), float64(last), ok)
+	}
+	for series, value := range u.changes.gauges_f {
+		last, ok := u.p.last.gauges_f[series]
+		add(series, value, last, ok)
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].added != changes[j].added {
+			return changes[i].added
+		}
+		if changes[i].magnitude != changes[j].magnitude {
+			return changes[i].magnitude > changes[j].magnitude
+		}
+		return changes[i].series < changes[j].series
+	})
+
+	n := u.p.opt.TopChanges
+	if n == 0 {
+		n = defaultTopChanges
+	}
+	if n < 0 || n > len(changes) {
+		n = len(changes)
+	}
+	top := make([]string, 0, n)
+	for _, c := range changes[:n] {
+		if c.added {
+			top = append(top, fmt.Sprintf("%s=%v (new)", c.series, c.value))
+		} else {
+			top = append(top, fmt.Sprintf("%s=%v (%+g)", c.series, c.value, c.value-c.last))
+		}
+	}
+	u.p.logf(levelVerbose, "changes to flush: %d series (counters=%d, gauges=%d, gauges_f=%d), largest: %s",
+		len(changes), len(u.changes.counters), len(u.changes.gauges), len(u.changes.gauges_f), strings.Join(top, ", "))
+}
@@ -0,0 +1,50 @@
+package signalfx
+
+import (
+	. "gopkg.in/check.v1"
+)
+
+func (s *Zuite) TestLogChanges(c *C) {
+	logger := &recordingLogger{}
+	p := newPublisher("", Options{Logger: logger, Verbose: true, TopChanges: 2})
+	p.last.counters["requests"] = 10
+	p.last.gauges["queue"] = 5
+	p.last.gauges_f["load"] = 0.5
+
+	u := p.prepareUpdate()
+	u.changes.counters["requests"] = 12
+	u.changes.gauges["queue"] = 105
+	u.changes.gauges_f["load"] = 0.25
+	u.changes.gauges["workers"] = 3
+	u.logChanges()
+
+	c.Assert(logger.lines, DeepEquals, []string{
+		"changes to flush: 4 series (counters=1, gauges=2, gauges_f=1), largest: workers=3 (new), queue=105 (+100)",
+	})
+}
+
+func (s *Zuite) TestLogChanges_all(c *C) {
+	logger := &recordingLogger{}
+	p := newPublisher("", Options{Logger: logger, Verbose: true, TopChanges: -1})
+	p.last.gauges_f["load"] = 0.5
+
+	u := p.prepareUpdate()
+	u.changes.gauges_f["load"] = 0.25
+	u.changes.counters["requests"] = 1
+	u.logChanges()
+
+	c.Assert(logger.lines, DeepEquals, []string{
+		"changes to flush: 2 series (counters=1, gauges=0, gauges_f=1), largest: requests=1 (new), load=0.25 (-0.25)",
+	})
+}
+
+func (s *Zuite) TestLogChanges_notVerbose(c *C) {
+	logger := &recordingLogger{}
+	p := newPublisher("", Options{Logger: logger})
+
+	u := p.prepareUpdate()
+	u.changes.counters["requests"] = 1
+	u.logChanges()
+
+	c.Assert(logger.lines, HasLen, 0)
+}
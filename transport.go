@@ -0,0 +1,252 @@
+package signalfx
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/signalfx/golib/sfxclient"
+)
+
+// connect creates the client used to send datapoints to SignalFX, along with
+// a fresh underlying transport.
+func (p *publisher) connect() error {
+	var base http.RoundTripper
+	switch {
+	case p.opt.Transport != nil:
+		base = p.opt.Transport
+	case p.opt.HTTPClient != nil && p.opt.HTTPClient.Transport != nil:
+		base = p.opt.HTTPClient.Transport
+	default:
+		built, err := newBaseTransport(p.opt)
+		if err != nil {
+			return err
+		}
+		base = built
+	}
+	p.transport.closeIdleConnections()
+	p.transport.base = base
+	p.transport.owned = p.opt.Transport == nil && (p.opt.HTTPClient == nil || p.opt.HTTPClient.Transport == nil)
+	p.client = sfxclient.NewHTTPSink()
+	if p.opt.HTTPClient != nil {
+		p.client.Client = *p.opt.HTTPClient
+	}
+	p.client.AuthToken = p.authToken
+	p.client.Client.Transport = p.transport
+	if p.opt.Realm != "" {
+		p.client.DatapointEndpoint = realmIngest(p.opt.Realm) + datapointPath
+		p.client.EventEndpoint = realmIngest(p.opt.Realm) + eventPath
+	}
+	if p.opt.DatapointEndpoint != "" {
+		p.client.DatapointEndpoint = p.opt.DatapointEndpoint
+	}
+	if p.opt.EventEndpoint != "" {
+		p.client.EventEndpoint = p.opt.EventEndpoint
+	}
+	p.useEndpoint()
+	p.sender = p.client
+	if p.opt.Encoder != nil {
+		p.sender = &encodingSender{client: p.client, encoder: p.opt.Encoder}
+	}
+	return nil
+}
+
+// disconnect drops the client after a failure, for a new one to be created
+// on the next flush. Injected senders are kept.
+func (p *publisher) disconnect() {
+	if p.client != nil {
+		p.client = nil
+		p.sender = nil
+	}
+}
+
+// newBaseTransport creates the transport used to reach SignalFX, configured
+// as per the options.
+func newBaseTransport(opt Options) (*http.Transport, error) {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	proxy, err := proxyFunc(opt, os.Getenv)
+	if err != nil {
+		return nil, err
+	}
+	t.Proxy = proxy
+	t.DialContext = dialContext(opt)
+	if opt.TLSConfig != nil {
+		t.TLSClientConfig = opt.TLSConfig.Clone()
+	}
+	cert := opt.ClientCertificate
+	if cert == nil && opt.ClientCertificateFile != "" {
+		loaded, err := tls.LoadX509KeyPair(opt.ClientCertificateFile, opt.ClientKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		cert = &loaded
+	}
+	if cert != nil {
+		t.TLSClientConfig = tlsConfig(t)
+		t.TLSClientConfig.Certificates = []tls.Certificate{*cert}
+	}
+	return t, nil
+}
+
+// dialContext creates the function dialing connections, configured as per
+// the options.
+func dialContext(opt Options) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+	if opt.DialTimeout != 0 {
+		dialer.Timeout = opt.DialTimeout
+	}
+	if opt.DialKeepAlive != 0 {
+		dialer.KeepAlive = opt.DialKeepAlive
+	}
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if network == "tcp" && opt.DialNetwork != "" {
+			network = opt.DialNetwork
+		}
+		return dialer.DialContext(ctx, network, addr)
+	}
+}
+
+// proxyFunc determines the proxy to use: the SOCKS5 or HTTP proxy from the
+// options, or else the proxy from the environment, falling back to
+// ALL_PROXY when it specifies a SOCKS5 proxy.
+func proxyFunc(opt Options, getenv func(string) string) (func(*http.Request) (*url.URL, error), error) {
+	if opt.SOCKS5Proxy != "" && opt.HTTPProxy != "" {
+		return nil, fmt.Errorf("SOCKS5Proxy and HTTPProxy are mutually exclusive")
+	}
+	if opt.SOCKS5Proxy != "" {
+		return socks5Proxy(opt.SOCKS5Proxy)
+	}
+	if opt.HTTPProxy != "" {
+		return httpProxy(opt)
+	}
+	if getenv("HTTPS_PROXY") == "" && getenv("https_proxy") == "" {
+		if all := firstNonEmpty(getenv("ALL_PROXY"), getenv("all_proxy")); strings.HasPrefix(all, "socks5://") {
+			return socks5Proxy(all)
+		}
+	}
+	return http.ProxyFromEnvironment, nil
+}
+
+func socks5Proxy(rawurl string) (func(*http.Request) (*url.URL, error), error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "socks5" {
+		return nil, fmt.Errorf("invalid SOCKS5 proxy %q: scheme must be socks5", rawurl)
+	}
+	return http.ProxyURL(u), nil
+}
+
+// httpProxy returns the HTTP proxy of the options, authenticated with the
+// credentials of the options, if any, rather than those of its URL.
+func httpProxy(opt Options) (func(*http.Request) (*url.URL, error), error) {
+	u, err := url.Parse(opt.HTTPProxy)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid HTTP proxy %q: scheme must be http or https", opt.HTTPProxy)
+	}
+	if opt.HTTPProxyUser != "" {
+		u.User = url.UserPassword(opt.HTTPProxyUser, opt.HTTPProxyPassword)
+	}
+	return http.ProxyURL(u), nil
+}
+
+// tlsConfig returns a copy of the transport's TLS configuration, to be
+// customized.
+func tlsConfig(t *http.Transport) *tls.Config {
+	if t.TLSClientConfig == nil {
+		return &tls.Config{}
+	}
+	return t.TLSClientConfig.Clone()
+}
+
+// instrumentedTransport observes the requests made to SignalFX: it counts
+// the bytes of request bodies, and measures the clock skew against the Date
+// header of responses. It also calls the request hook, if any.
+type instrumentedTransport struct {
+	base  http.RoundTripper
+	hook  func(*http.Request) error
+	bytes int64
+
+	// owned is set when the base transport was created by the publisher,
+	// rather than injected.
+	owned bool
+
+	// skew is the last measured skew, in nanoseconds, and skewKnown is 1
+	// once it was measured.
+	skew      int64
+	skewKnown int32
+}
+
+func (t *instrumentedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.hook != nil {
+		// Round trippers must not modify the request they are given.
+		req = req.Clone(req.Context())
+		if err := t.hook(req); err != nil {
+			if req.Body != nil {
+				req.Body.Close()
+			}
+			return nil, err
+		}
+	}
+	if req.ContentLength > 0 {
+		atomic.AddInt64(&t.bytes, req.ContentLength)
+	}
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	sent := time.Now()
+	resp, err := base.RoundTrip(req)
+	if err == nil {
+		if date, err := http.ParseTime(resp.Header.Get("Date")); err == nil {
+			t.observeDate(date, sent, time.Now())
+		}
+	}
+	return resp, err
+}
+
+// observeDate records the skew between the server's date and the local time
+// at which the server most likely produced its response. Since the date has
+// a resolution of a second, it is assumed to be in the middle of its second.
+func (t *instrumentedTransport) observeDate(date, sent, received time.Time) {
+	local := sent.Add(received.Sub(sent) / 2)
+	server := date.Add(500 * time.Millisecond)
+	atomic.StoreInt64(&t.skew, int64(server.Sub(local)))
+	atomic.StoreInt32(&t.skewKnown, 1)
+}
+
+// closeIdleConnections closes the idle connections of the base transport,
+// unless it was injected.
+func (t *instrumentedTransport) closeIdleConnections() {
+	if base, ok := t.base.(*http.Transport); ok && t.owned {
+		base.CloseIdleConnections()
+	}
+}
+
+// sent returns the total number of bytes sent.
+func (t *instrumentedTransport) sent() int64 {
+	return atomic.LoadInt64(&t.bytes)
+}
+
+// clockSkew returns the last measured skew of the server's clock ahead of
+// the local clock, if any was measured.
+func (t *instrumentedTransport) clockSkew() (time.Duration, bool) {
+	if atomic.LoadInt32(&t.skewKnown) == 0 {
+		return 0, false
+	}
+	return time.Duration(atomic.LoadInt64(&t.skew)), true
+}
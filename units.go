@@ -0,0 +1,37 @@
+package signalfx
+
+import "time"
+
+// Unit is the unit of the values of a metric, declared in Options.Units.
+type Unit string
+
+// Units commonly declared.
+const (
+	UnitNanoseconds  Unit = "ns"
+	UnitMicroseconds Unit = "us"
+	UnitMilliseconds Unit = "ms"
+	UnitSeconds      Unit = "s"
+	UnitBytes        Unit = "bytes"
+	UnitPercent      Unit = "percent"
+)
+
+// unitDimension is the dimension carrying the unit of a metric.
+const unitDimension = "unit"
+
+// durations are the time units, and their length.
+var durations = map[Unit]time.Duration{
+	UnitNanoseconds:  time.Nanosecond,
+	UnitMicroseconds: time.Microsecond,
+	UnitMilliseconds: time.Millisecond,
+	UnitSeconds:      time.Second,
+}
+
+// scale returns the factor by which durations recorded by go-metrics, in
+// nanoseconds, are divided to be expressed in the unit. It is 1 for units
+// which are not time units.
+func (unit Unit) scale() float64 {
+	if d, ok := durations[unit]; ok {
+		return float64(d)
+	}
+	return 1
+}
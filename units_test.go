@@ -0,0 +1,55 @@
+package signalfx
+
+import (
+	"time"
+
+	metrics "github.com/rcrowley/go-metrics"
+	. "gopkg.in/check.v1"
+)
+
+func (s *Zuite) TestUnits(c *C) {
+	p := newPublisher("", Options{Units: map[string]Unit{
+		"latency": UnitMilliseconds,
+		"heap":    UnitBytes,
+	}})
+	u := p.prepareUpdate()
+
+	timer := metrics.NewTimer()
+	timer.Update(1500 * time.Microsecond)
+	u.metricToDatapoints("latency", timer)
+	gauge := metrics.NewGauge()
+	gauge.Update(2048)
+	u.metricToDatapoints("heap", gauge)
+
+	values := make(map[string]string)
+	for _, d := range u.ds {
+		c.Assert(d.Dimensions[unitDimension], Not(Equals), "")
+		values[d.Metric] = d.Value.String()
+	}
+	c.Assert(values["latency.count"], Equals, "1")
+	c.Assert(values["latency.max"], Equals, "1.5")
+	c.Assert(values["latency.mean"], Equals, "1.5")
+	c.Assert(values["heap"], Equals, "2048")
+	c.Assert(u.ds[len(u.ds)-1].Dimensions, DeepEquals, map[string]string{unitDimension: "bytes"})
+}
+
+func (s *Zuite) TestUnits_subunitDurations(c *C) {
+	r := metrics.NewRegistry()
+	latency := NewSummedTimer()
+	r.Register("latency", latency)
+	latency.Update(400 * time.Microsecond)
+
+	batch := Collect(r, Options{Units: map[string]Unit{"latency": UnitMilliseconds}})
+	for _, suffix := range []string{"min", "max", "sum"} {
+		point, ok := batch.Get("latency."+suffix, map[string]string{unitDimension: "ms"})
+		c.Assert(ok, Equals, true)
+		c.Assert(point.Value, Equals, 0.4)
+	}
+}
+
+func (s *Zuite) TestUnit_scale(c *C) {
+	c.Assert(UnitNanoseconds.scale(), Equals, 1.0)
+	c.Assert(UnitSeconds.scale(), Equals, 1e9)
+	c.Assert(UnitBytes.scale(), Equals, 1.0)
+	c.Assert(Unit("").scale(), Equals, 1.0)
+}
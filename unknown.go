@@ -0,0 +1,20 @@
+package signalfx
+
+// SkipUnknownMetrics is an Options.OnUnknownMetric skipping the metrics of
+// unknown types silently.
+func SkipUnknownMetrics(name string, metric interface{}) {}
+
+// unknown handles a metric of a type the publisher does not know, and which
+// no converter converts, see Options.OnUnknownMetric. By default, it is
+// logged, once per name.
+func (u *update) unknown(name string, metric interface{}) {
+	if u.p.opt.OnUnknownMetric != nil {
+		u.p.opt.OnUnknownMetric(name, metric)
+		return
+	}
+	if _, ok := u.p.unknown[name]; ok {
+		return
+	}
+	u.p.unknown[name] = struct{}{}
+	u.p.logf(levelWarning, "WARNING: skipping metric %s of unknown type %T", name, metric)
+}
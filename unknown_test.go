@@ -0,0 +1,28 @@
+package signalfx
+
+import (
+	metrics "github.com/rcrowley/go-metrics"
+	. "gopkg.in/check.v1"
+)
+
+func (s *Zuite) TestUnknownMetrics(c *C) {
+	logger := &recordingLogger{}
+	p, sender := newTestPublisher(Options{Logger: logger})
+	r := customRegistry{metrics.NewRegistry(), map[string]interface{}{"odd": struct{}{}}}
+	metrics.GetOrRegisterCounter("calls", r).Inc(1)
+
+	c.Assert(p.single(r), IsNil)
+	c.Assert(p.single(r), IsNil)
+	c.Assert(logger.lines, DeepEquals, []string{"WARNING: skipping metric odd of unknown type struct {}"})
+	_, ok := newBatch(sender.sent).Get("calls", nil)
+	c.Assert(ok, Equals, true)
+}
+
+func (s *Zuite) TestUnknownMetrics_callback(c *C) {
+	var unknown []string
+	r := customRegistry{metrics.NewRegistry(), map[string]interface{}{"odd": 1}}
+	Collect(r, Options{OnUnknownMetric: func(name string, metric interface{}) {
+		unknown = append(unknown, name)
+	}})
+	c.Assert(unknown, DeepEquals, []string{"odd"})
+}
@@ -0,0 +1,106 @@
+package signalfx
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/signalfx/golib/datapoint"
+)
+
+// maxDimensionKeyLength is the longest dimension key SignalFX accepts.
+const maxDimensionKeyLength = 128
+
+// validate checks the datapoint of the series identified by key before it
+// enters a batch, so that a single bad metric does not cause SignalFX to
+// reject the whole batch. Datapoints without a name or with a value which is
+// not finite are dropped, and illegal dimension keys are fixed. It returns
+// whether the datapoint should be sent. Each problem is logged once per series.
+func (u *update) validate(key string, d *datapoint.Datapoint) bool {
+	if d.Metric == "" {
+		u.stats.Invalid++
+		u.invalid(key, "dropping datapoint with an empty metric name")
+		return false
+	}
+	if v, ok := d.Value.(datapoint.FloatValue); ok {
+		if f := v.Float(); math.IsNaN(f) || math.IsInf(f, 0) {
+			u.stats.Invalid++
+			u.invalid(key, "dropping datapoint %s with non-finite value %v", d.Metric, f)
+			return false
+		}
+	}
+	var fixed map[string]string
+	for k, v := range d.Dimensions {
+		legal := legalDimensionKey(k)
+		if legal == k && v != "" {
+			continue
+		}
+		if fixed == nil {
+			u.stats.Invalid++
+			fixed = make(map[string]string, len(d.Dimensions))
+			for k, v := range d.Dimensions {
+				fixed[k] = v
+			}
+		}
+		delete(fixed, k)
+		switch {
+		case v == "":
+			u.invalid(key, "removing dimension %q with an empty value from %s", k, d.Metric)
+		case legal == "":
+			u.invalid(key, "removing dimension %q with a reserved key from %s", k, d.Metric)
+		default:
+			u.invalid(key, "renaming dimension %q of %s to %q", k, d.Metric, legal)
+			fixed[legal] = v
+		}
+	}
+	if fixed != nil {
+		d.Dimensions = fixed
+	}
+	return true
+}
+
+// legalDimensions returns the dimensions as fixed by validation.
+func legalDimensions(dims map[string]string) map[string]string {
+	legal := make(map[string]string, len(dims))
+	for k, v := range dims {
+		if key := legalDimensionKey(k); key != "" && v != "" {
+			legal[key] = v
+		}
+	}
+	return legal
+}
+
+// invalid logs a problem found while validating the series identified by
+// key, unless it was already logged for it.
+func (u *update) invalid(key string, format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	if _, ok := u.p.invalid[key+"\x00"+msg]; ok {
+		return
+	}
+	u.p.invalid[key+"\x00"+msg] = struct{}{}
+	u.p.logf(levelWarning, "WARNING: %s", msg)
+}
+
+// legalDimensionKey turns key into a dimension key SignalFX accepts: it must
+// start with a letter, only contain letters, digits, underscores and dashes,
+// and be at most 128 characters long. Keys using a prefix reserved by
+// SignalFX cannot be fixed, and yield an empty string.
+func legalDimensionKey(key string) string {
+	if strings.HasPrefix(key, "sf_") {
+		return ""
+	}
+	legal := strings.Map(func(r rune) rune {
+		switch {
+		case 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z', '0' <= r && r <= '9', r == '_', r == '-':
+			return r
+		}
+		return '_'
+	}, key)
+	if legal == "" || !('a' <= legal[0] && legal[0] <= 'z' || 'A' <= legal[0] && legal[0] <= 'Z') {
+		legal = "dim_" + legal
+	}
+	if len(legal) > maxDimensionKeyLength {
+		legal = legal[:maxDimensionKeyLength]
+	}
+	return legal
+}
@@ -0,0 +1,47 @@
+package signalfx
+
+import (
+	"math"
+
+	metrics "github.com/rcrowley/go-metrics"
+	. "gopkg.in/check.v1"
+)
+
+func (s *Zuite) TestValidate(c *C) {
+	logger := &recordingLogger{}
+	p := newPublisher("", Options{Logger: logger})
+	u := p.prepareUpdate()
+
+	u.appendIfGaugeFChanged("nan", math.NaN())
+	u.appendIfGaugeFChanged("nan", math.NaN())
+	u.appendIfGaugeFChanged("inf", math.Inf(1))
+	u.appendIfCounterChanged("", 1)
+	u.metricToDatapoints(NameWithDimensions("fixed", map[string]string{
+		"host.name": "a",
+		"sf_metric": "b",
+		"empty":     "",
+		"ok":        "c",
+	}), metrics.NewCounter())
+
+	c.Assert(u.ds, HasLen, 1)
+	c.Assert(u.ds[0].Metric, Equals, "fixed")
+	c.Assert(u.ds[0].Dimensions, DeepEquals, map[string]string{"host_name": "a", "ok": "c"})
+	c.Assert(u.stats.Invalid, Equals, 5)
+	c.Assert(u.changes.gauges_f, HasLen, 0)
+	c.Assert(logger.lines, HasLen, 6)
+	c.Assert(logger.lines[0], Equals, "WARNING: dropping datapoint nan with non-finite value NaN")
+}
+
+func (s *Zuite) TestLegalDimensionKey(c *C) {
+	for key, legal := range map[string]string{
+		"host":      "host",
+		"host.name": "host_name",
+		"k8s-pod":   "k8s-pod",
+		"1st":       "dim_1st",
+		"":          "dim_",
+		"sf_hires":  "",
+		"région":    "r_gion",
+	} {
+		c.Assert(legalDimensionKey(key), Equals, legal, Commentf("key %q", key))
+	}
+}
@@ -0,0 +1,149 @@
+package signalfx
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultAPIEndpoint is the endpoint of the SignalFX API, in the us0 realm.
+const DefaultAPIEndpoint = "https://api.signalfx.com"
+
+// Verifier checks through the SignalFX API that datapoints were ingested,
+// for end-to-end verification of a configuration, for instance in staging:
+//
+// 	v := &signalfx.Verifier{Token: apiToken}
+// 	ctx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+// 	defer cancel()
+// 	err := v.VerifyDelivery(ctx, "requests[env=staging]", start)
+type Verifier struct {
+	// Token is an access token with the API scope. Ingest tokens cannot
+	// read datapoints.
+	Token string
+
+	// Endpoint is the API endpoint. By default, this is the endpoint of the
+	// Realm, e.g. "https://api.us1.signalfx.com", or DefaultAPIEndpoint
+	// without a realm.
+	Endpoint string
+	Realm    string
+
+	// Interval is the time between queries, as ingested datapoints take
+	// a few seconds to be readable. By default, this is 5 seconds.
+	Interval time.Duration
+
+	// Client is the HTTP client used to query the API. By default, this is
+	// http.DefaultClient.
+	Client *http.Client
+}
+
+// VerifyDelivery queries the API until SignalFX has a datapoint of the
+// metric since the time, and fails once the context is done. The name can
+// carry dimensions, as produced by NameWithDimensions, to only match the
+// series having them.
+func (v *Verifier) VerifyDelivery(ctx context.Context, name string, since time.Time) error {
+	interval := v.Interval
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	// last is the outcome of the last query not interrupted by the context.
+	last := fmt.Errorf("no datapoint")
+	for {
+		found, err := v.query(ctx, name, since)
+		if found {
+			return nil
+		}
+		if ctx.Err() == nil {
+			last = err
+			if err == nil {
+				last = fmt.Errorf("no datapoint")
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("verifying delivery of %s since %s: %s (%s)", name, since.Format(time.RFC3339), ctx.Err(), last)
+		case <-time.After(interval):
+		}
+	}
+}
+
+// timeSeriesWindow is the response of the time series window API, keyed by
+// time series id, each datapoint being a timestamp and a value.
+type timeSeriesWindow struct {
+	Data   map[string][][2]float64 `json:"data"`
+	Errors []interface{}           `json:"errors"`
+}
+
+// query reports whether the API has datapoints of the metric since the time.
+func (v *Verifier) query(ctx context.Context, name string, since time.Time) (bool, error) {
+	values, err := v.values(ctx, name, since, time.Now())
+	return len(values) != 0, err
+}
+
+// values returns the values the API has of the metric between the times.
+func (v *Verifier) values(ctx context.Context, name string, start, end time.Time) ([]float64, error) {
+	endpoint := v.Endpoint
+	switch {
+	case endpoint != "":
+	case v.Realm != "":
+		endpoint = "https://api." + v.Realm + ".signalfx.com"
+	default:
+		endpoint = DefaultAPIEndpoint
+	}
+	client := v.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	params := url.Values{
+		"query":      {verifyQuery(name)},
+		"startMs":    {strconv.FormatInt(start.UnixNano()/int64(time.Millisecond), 10)},
+		"endMs":      {strconv.FormatInt(end.UnixNano()/int64(time.Millisecond), 10)},
+		"resolution": {"1000"},
+	}
+	req, err := http.NewRequest("GET", strings.TrimSuffix(endpoint, "/")+"/v1/timeserieswindow?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-SF-Token", v.Token)
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, fmt.Errorf("invalid status code %d: %s", resp.StatusCode, body)
+	}
+	var window timeSeriesWindow
+	if err := json.NewDecoder(resp.Body).Decode(&window); err != nil {
+		return nil, err
+	}
+	var values []float64
+	for _, points := range window.Data {
+		for _, point := range points {
+			values = append(values, point[1])
+		}
+	}
+	return values, nil
+}
+
+// verifyQuery is the search query matching the series of the name.
+func verifyQuery(name string) string {
+	metric, dims := parseName(name)
+	terms := []string{"sf_metric:" + strconv.Quote(metric)}
+	keys := make([]string, 0, len(dims))
+	for key := range dims {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		terms = append(terms, key+":"+strconv.Quote(dims[key]))
+	}
+	return strings.Join(terms, " AND ")
+}
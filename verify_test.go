@@ -0,0 +1,70 @@
+package signalfx
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *Zuite) TestVerifyQuery(c *C) {
+	c.Assert(verifyQuery("requests"), Equals, `sf_metric:"requests"`)
+	c.Assert(verifyQuery("requests[env=prod,az=a]"), Equals, `sf_metric:"requests" AND az:"a" AND env:"prod"`)
+}
+
+func (s *Zuite) TestVerifyDelivery(c *C) {
+	queries := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		c.Check(req.URL.Path, Equals, "/v1/timeserieswindow")
+		c.Check(req.URL.Query().Get("query"), Equals, `sf_metric:"requests"`)
+		c.Check(req.URL.Query().Get("startMs"), Equals, "1000")
+		c.Check(req.Header.Get("X-SF-Token"), Equals, "api")
+		queries++
+		if queries < 3 {
+			w.Write([]byte(`{"data":{"AAAA":[]},"errors":[]}`))
+			return
+		}
+		w.Write([]byte(`{"data":{"AAAA":[[1500,4]]},"errors":[]}`))
+	}))
+	defer server.Close()
+
+	v := &Verifier{Token: "api", Endpoint: server.URL, Interval: time.Millisecond}
+	c.Assert(v.VerifyDelivery(context.Background(), "requests", time.Unix(1, 0)), IsNil)
+	c.Assert(queries, Equals, 3)
+}
+
+func (s *Zuite) TestVerifyDelivery_timeout(c *C) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte("unauthorized"))
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	v := &Verifier{Token: "api", Endpoint: server.URL, Interval: time.Millisecond}
+	c.Assert(v.VerifyDelivery(ctx, "requests", time.Unix(1, 0)), ErrorMatches,
+		`verifying delivery of requests since .*: context deadline exceeded \(invalid status code 401: unauthorized\)`)
+}
+
+// roundTripperFunc is an http.RoundTripper calling itself.
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func (s *Zuite) TestVerifyDelivery_realm(c *C) {
+	var host string
+	v := &Verifier{Realm: "eu0", Interval: time.Millisecond, Client: &http.Client{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		host = req.URL.Host
+		return nil, errors.New("unreachable")
+	})}}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	c.Assert(v.VerifyDelivery(ctx, "requests", time.Unix(1, 0)), NotNil)
+	c.Assert(host, Equals, "api.eu0.signalfx.com")
+}
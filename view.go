@@ -0,0 +1,168 @@
+package signalfx
+
+import (
+	metrics "github.com/rcrowley/go-metrics"
+)
+
+// View is a registry shaped from another one: its metrics are filtered,
+// renamed and have their dimensions transformed, so that a registry can feed
+// differently shaped exports, e.g. a public subset to SignalFX and the full
+// set to a dry run. Views compose, the Registry of a view being possibly
+// another view:
+//
+// 	public := &signalfx.View{
+// 		Registry: metrics.DefaultRegistry,
+// 		Filters:  []signalfx.Filter{signalfx.FilterFunc(isPublic)},
+// 		Rename:   func(name string) string { return "public." + name },
+// 	}
+// 	go signalfx.PublishToSignalFx(public, "<auth_token>")
+//
+// Metrics registered through a view are registered in the underlying
+// registry, under the name given unless scoped. Namespace and Sarama are
+// views.
+type View struct {
+	Registry metrics.Registry
+
+	// Filters decide which metrics of the registry are in the view, from
+	// their name in the registry.
+	Filters []Filter
+
+	// Rename, if set, renames the metrics, from their name in the registry
+	// without dimensions. The name returned may have dimensions, encoded by
+	// NameWithDimensions, added to those of the metric. Metrics renamed to
+	// "" are left out of the view.
+	Rename func(name string) string
+
+	// Dimensions, if set, transforms the dimensions of the metrics, given
+	// their name in the view.
+	Dimensions func(name string, dims map[string]string) map[string]string
+
+	// Scope, if set, converts the names given to Get, GetOrRegister,
+	// Register and Unregister into names of the registry, e.g. the reverse
+	// of the Rename. Otherwise, metrics are looked up by their name in the
+	// view, and registered under the name given.
+	Scope func(name string) string
+}
+
+var _ metrics.Registry = &View{}
+
+// name returns the name of the metric in the view, reporting whether it is
+// in the view.
+func (v *View) name(name string, i interface{}) (string, bool) {
+	if !keep(v.Filters, name, i) {
+		return "", false
+	}
+	base, dims := parseName(name)
+	if v.Rename != nil {
+		renamed := v.Rename(base)
+		if renamed == "" {
+			return "", false
+		}
+		var added map[string]string
+		base, added = parseName(renamed)
+		dims = mergeDimensions(dims, added)
+	}
+	if v.Dimensions != nil {
+		dims = v.Dimensions(base, dims)
+	}
+	return base + encodeDimensions(dims), true
+}
+
+// Each calls f with the metrics of the view, under their name in the view.
+func (v *View) Each(f func(string, interface{})) {
+	v.Registry.Each(func(name string, i interface{}) {
+		if viewed, ok := v.name(name, i); ok {
+			f(viewed, i)
+		}
+	})
+}
+
+// underlying returns the name in the registry of the metric named name in
+// the view, and the metric, if any.
+func (v *View) underlying(name string) (string, interface{}) {
+	var (
+		found  string
+		metric interface{}
+	)
+	v.Registry.Each(func(underlying string, i interface{}) {
+		if viewed, ok := v.name(underlying, i); ok && viewed == name && metric == nil {
+			found, metric = underlying, i
+		}
+	})
+	return found, metric
+}
+
+// Get returns the metric named name in the view, or nil.
+func (v *View) Get(name string) interface{} {
+	if v.Scope != nil {
+		return v.Registry.Get(v.Scope(name))
+	}
+	_, metric := v.underlying(name)
+	return metric
+}
+
+// GetAll returns the values of the metrics of the view, by their name in
+// the view.
+func (v *View) GetAll() map[string]map[string]interface{} {
+	all := make(map[string]map[string]interface{})
+	values := v.Registry.GetAll()
+	v.Registry.Each(func(name string, i interface{}) {
+		if viewed, ok := v.name(name, i); ok {
+			all[viewed] = values[name]
+		}
+	})
+	return all
+}
+
+// GetOrRegister gets the metric of the underlying registry under the name
+// given unless scoped, or registers i under that name.
+func (v *View) GetOrRegister(name string, i interface{}) interface{} {
+	if v.Scope != nil {
+		name = v.Scope(name)
+	}
+	return v.Registry.GetOrRegister(name, i)
+}
+
+// Register registers the metric in the underlying registry, under the name
+// given unless scoped.
+func (v *View) Register(name string, i interface{}) error {
+	if v.Scope != nil {
+		name = v.Scope(name)
+	}
+	return v.Registry.Register(name, i)
+}
+
+// RunHealthchecks runs the healthchecks of the view.
+func (v *View) RunHealthchecks() {
+	v.Each(func(_ string, i interface{}) {
+		if h, ok := i.(metrics.Healthcheck); ok {
+			h.Check()
+		}
+	})
+}
+
+// Unregister unregisters the metric named name in the view from the
+// underlying registry.
+func (v *View) Unregister(name string) {
+	if v.Scope != nil {
+		v.Registry.Unregister(v.Scope(name))
+		return
+	}
+	if underlying, metric := v.underlying(name); metric != nil {
+		v.Registry.Unregister(underlying)
+	}
+}
+
+// UnregisterAll unregisters the metrics of the view from the underlying
+// registry.
+func (v *View) UnregisterAll() {
+	var names []string
+	v.Registry.Each(func(name string, i interface{}) {
+		if _, ok := v.name(name, i); ok {
+			names = append(names, name)
+		}
+	})
+	for _, name := range names {
+		v.Registry.Unregister(name)
+	}
+}
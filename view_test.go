@@ -0,0 +1,50 @@
+package signalfx
+
+import (
+	"strings"
+
+	metrics "github.com/rcrowley/go-metrics"
+	. "gopkg.in/check.v1"
+)
+
+func (s *Zuite) TestView(c *C) {
+	r := metrics.NewRegistry()
+	metrics.GetOrRegisterCounter("orders[env=prod,customer=42]", r).Inc(3)
+	metrics.GetOrRegisterCounter("internal.gc", r).Inc(1)
+	metrics.GetOrRegisterGauge("queue", r).Update(2)
+
+	public := &View{
+		Registry: r,
+		Filters: []Filter{FilterFunc(func(name string, _ interface{}) bool {
+			return !strings.HasPrefix(name, "internal.")
+		})},
+		Rename: func(name string) string { return "public." + name },
+		Dimensions: func(_ string, dims map[string]string) map[string]string {
+			delete(dims, "customer")
+			return dims
+		},
+	}
+
+	names := make(map[string]bool)
+	public.Each(func(name string, _ interface{}) {
+		names[name] = true
+	})
+	c.Assert(names, DeepEquals, map[string]bool{"public.orders[env=prod]": true, "public.queue": true})
+	c.Assert(public.Get("public.orders[env=prod]").(metrics.Counter).Count(), Equals, int64(3))
+	c.Assert(public.Get("internal.gc"), IsNil)
+	c.Assert(public.GetAll(), HasLen, 2)
+
+	// Views compose.
+	queues := &View{Registry: public, Filters: []Filter{FilterFunc(func(name string, _ interface{}) bool {
+		return strings.HasSuffix(name, "queue")
+	})}}
+	batch := Collect(queues, Options{})
+	c.Assert(batch, HasLen, 1)
+	c.Assert(batch[0].Name, Equals, "public.queue")
+
+	public.Unregister("public.queue")
+	c.Assert(r.Get("queue"), IsNil)
+	public.UnregisterAll()
+	c.Assert(r.Get("orders[env=prod,customer=42]"), IsNil)
+	c.Assert(r.Get("internal.gc"), NotNil)
+}
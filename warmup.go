@@ -0,0 +1,64 @@
+package signalfx
+
+import (
+	"sync"
+	"time"
+)
+
+// warmUp holds publishing back after start, see Options.WarmUp.
+type warmUp struct {
+	mu sync.Mutex
+
+	// until is the end of the warm-up period, zero if unbounded.
+	until     time.Time
+	waitReady bool
+	ready     bool
+	over      bool
+}
+
+func newWarmUp(opt Options, start time.Time) *warmUp {
+	w := &warmUp{waitReady: opt.WaitForReady}
+	if opt.WarmUp > 0 {
+		w.until = start.Add(opt.WarmUp)
+	}
+	w.over = w.until.IsZero() && !w.waitReady
+	return w
+}
+
+func (w *warmUp) setReady() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.ready = true
+}
+
+// warming reports whether publishing is still held back, and whether the
+// warm-up just ended.
+func (w *warmUp) warming(now time.Time) (warming, ended bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.over {
+		return false, false
+	}
+	if !w.ready && (w.until.IsZero() || now.Before(w.until)) {
+		return true, false
+	}
+	w.over = true
+	return false, true
+}
+
+// Ready ends the warm-up, see Options.WarmUp and Options.WaitForReady. It
+// may be called before the publisher starts.
+func (pub *Publisher) Ready() {
+	pub.p.warmUp.setReady()
+}
+
+// Ready ends the warm-up, see Options.WarmUp and Options.WaitForReady. It
+// may be called before the publisher starts.
+func (a *Admin) Ready() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.ready = true
+	if a.p != nil {
+		a.p.warmUp.setReady()
+	}
+}
@@ -0,0 +1,66 @@
+package signalfx
+
+import (
+	"time"
+
+	metrics "github.com/rcrowley/go-metrics"
+	. "gopkg.in/check.v1"
+)
+
+func (s *Zuite) TestWarmUp(c *C) {
+	start := time.Now()
+
+	w := newWarmUp(Options{}, start)
+	warming, ended := w.warming(start)
+	c.Assert(warming, Equals, false)
+	c.Assert(ended, Equals, false)
+
+	w = newWarmUp(Options{WarmUp: time.Minute}, start)
+	warming, _ = w.warming(start.Add(59 * time.Second))
+	c.Assert(warming, Equals, true)
+	warming, ended = w.warming(start.Add(time.Minute))
+	c.Assert(warming, Equals, false)
+	c.Assert(ended, Equals, true)
+	_, ended = w.warming(start.Add(2 * time.Minute))
+	c.Assert(ended, Equals, false)
+
+	// Ready ends the warm-up early.
+	w = newWarmUp(Options{WarmUp: time.Minute}, start)
+	w.setReady()
+	warming, ended = w.warming(start)
+	c.Assert(warming, Equals, false)
+	c.Assert(ended, Equals, true)
+
+	// Waiting for ready, for at most the warm-up.
+	w = newWarmUp(Options{WaitForReady: true}, start)
+	warming, _ = w.warming(start.Add(time.Hour))
+	c.Assert(warming, Equals, true)
+	w = newWarmUp(Options{WaitForReady: true, WarmUp: time.Minute}, start)
+	warming, _ = w.warming(start.Add(time.Minute))
+	c.Assert(warming, Equals, false)
+}
+
+func (s *Zuite) TestAdmin_Ready(c *C) {
+	admin := NewAdmin()
+	admin.Ready()
+	p := newPublisher("", Options{Admin: admin, WaitForReady: true})
+	warming, _ := p.warmUp.warming(time.Now())
+	c.Assert(warming, Equals, false)
+
+	admin = NewAdmin()
+	p = newPublisher("", Options{Admin: admin, WaitForReady: true})
+	warming, _ = p.warmUp.warming(time.Now())
+	c.Assert(warming, Equals, true)
+	admin.Ready()
+	warming, _ = p.warmUp.warming(time.Now())
+	c.Assert(warming, Equals, false)
+}
+
+func (s *Zuite) TestPublisher_Ready(c *C) {
+	pub := NewWithSender(metrics.NewRegistry(), &fakeSender{}, Options{WaitForReady: true})
+	warming, _ := pub.p.warmUp.warming(time.Now())
+	c.Assert(warming, Equals, true)
+	pub.Ready()
+	warming, _ = pub.p.warmUp.warming(time.Now())
+	c.Assert(warming, Equals, false)
+}